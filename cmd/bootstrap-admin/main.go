@@ -0,0 +1,104 @@
+// Command bootstrap-admin migrates the legacy EMAIL/PASSWORD (and optional ADMIN_TOTP_SECRET)
+// environment credentials into a portfolio admin record in the Users collection.
+// It only runs once: if a portfolio admin already exists it exits without changes.
+//
+// If an account with EMAIL already exists, for example an expense app user, it refuses to touch it unless
+// --promote is passed, in which case that account becomes the admin and its password is replaced.
+//
+//	go run ./cmd/bootstrap-admin [--promote]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"portfolio/database"
+	"portfolio/helpers"
+	"portfolio/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func main() {
+	promote := flag.Bool("promote", false, "make an existing account with EMAIL the admin, replacing its password")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	email := os.Getenv("EMAIL")
+	password := os.Getenv("PASSWORD")
+	if email == "" || password == "" {
+		log.Fatal("EMAIL and PASSWORD environment variables must be set")
+	}
+
+	if err := database.Ping(); err != nil {
+		log.Fatalf("Error connecting to the database: %v", err)
+	}
+
+	userCollection := database.PortfolioData(database.Client, "Users")
+
+	var existingAdmin models.User
+	err := userCollection.FindOne(ctx, bson.M{"role": models.RolePortfolioAdmin}).Decode(&existingAdmin)
+	if err == nil {
+		log.Printf("Portfolio admin %s already exists, nothing to do", existingAdmin.Email)
+		return
+	}
+	if err != mongo.ErrNoDocuments {
+		log.Fatalf("Error checking for existing admin: %v", err)
+	}
+
+	var existingUser models.User
+	err = userCollection.FindOne(ctx, bson.M{"email": email}).Decode(&existingUser)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Fatalf("Error checking for an existing account: %v", err)
+	}
+	accountExists := err == nil
+	if accountExists && !*promote {
+		log.Fatalf("An account with %s already exists; pass --promote to make it the portfolio admin and replace its password", email)
+	}
+
+	if err := helpers.PasswordPolicyFromEnv().Validate(password, email); err != nil {
+		log.Printf("Warning: PASSWORD does not meet the password policy: %v", err)
+	}
+
+	hashedPassword, err := helpers.HashPassword(password)
+	if err != nil {
+		log.Fatalf("Error hashing password: %v", err)
+	}
+
+	now := time.Now()
+	fields := bson.M{
+		"password":   hashedPassword,
+		"role":       models.RolePortfolioAdmin,
+		"verified":   true,
+		"updated_at": now,
+	}
+	if totpSecret := os.Getenv("ADMIN_TOTP_SECRET"); totpSecret != "" {
+		fields["totp_enabled"] = true
+		fields["totp_secret"] = totpSecret
+	}
+
+	if accountExists {
+		_, err = userCollection.UpdateOne(ctx, bson.M{"_id": existingUser.User_ID}, bson.M{"$set": fields})
+		if err != nil {
+			log.Fatalf("Error promoting %s: %v", email, err)
+		}
+		log.Printf("Existing account %s promoted to portfolio admin", email)
+	} else {
+		fields["_id"] = primitive.NewObjectID()
+		fields["name"] = "Admin"
+		fields["email"] = email
+		fields["created_at"] = now
+		if _, err := userCollection.InsertOne(ctx, fields); err != nil {
+			log.Fatalf("Error creating admin: %v", err)
+		}
+	}
+
+	log.Printf("Portfolio admin %s bootstrapped; EMAIL, PASSWORD and ADMIN_TOTP_SECRET can now be removed from the environment", email)
+}
@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"portfolio/helpers"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// accountPurgeInterval is how often accounts past their grace period are looked for
+const accountPurgeInterval = time.Hour
+
+// accountDeletionGracePeriod reads ACCOUNT_DELETION_GRACE_PERIOD (e.g. "336h"), defaulting to fourteen days
+func accountDeletionGracePeriod() time.Duration {
+	if period, err := time.ParseDuration(os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD")); err == nil && period >= 0 {
+		return period
+	}
+	return 14 * 24 * time.Hour
+}
+
+// userOwnedCollections hold documents referencing their owner by user_id
+func userOwnedCollections() []*mongo.Collection {
+	return []*mongo.Collection{
+		ExpenseItemCollection,
+		ExpenseCategoryCollection,
+		SessionCollection,
+		RefreshTokenCollection,
+		ApiKeyCollection,
+	}
+}
+
+// recordScrub is an update that removes a user's personal data from documents matching the filter
+type recordScrub struct {
+	collection *mongo.Collection
+	filter     bson.M
+	update     bson.M
+}
+
+// userRecordScrubs clear personal data the user left in records that are not theirs to delete: the invites
+// they redeemed and the admin actions they took, which stay in the audit log without the email
+func userRecordScrubs(user models.User) []recordScrub {
+	userID := user.User_ID.Hex()
+	return []recordScrub{
+		{InviteCollection, bson.M{"used_by.user_id": userID}, bson.M{"$pull": bson.M{"used_by": bson.M{"user_id": userID}}}},
+		{AuditLogCollection, bson.M{"actor_id": userID}, bson.M{"$set": bson.M{"actor_email": ""}}},
+	}
+}
+
+// purgeUser deletes the user and everything that belongs to them. The user record goes last so that an
+// interrupted purge is retried by the next run.
+func purgeUser(ctx context.Context, user models.User) error {
+	userID := user.User_ID.Hex()
+	for _, collection := range userOwnedCollections() {
+		if _, err := collection.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+			return fmt.Errorf("deleting from %s: %w", collection.Name(), err)
+		}
+	}
+	if _, err := OAuthStateCollection.DeleteMany(ctx, bson.M{"link_user_id": user.User_ID}); err != nil {
+		return fmt.Errorf("deleting from %s: %w", OAuthStateCollection.Name(), err)
+	}
+
+	for _, scrub := range userRecordScrubs(user) {
+		if _, err := scrub.collection.UpdateMany(ctx, scrub.filter, scrub.update); err != nil {
+			return fmt.Errorf("scrubbing %s: %w", scrub.collection.Name(), err)
+		}
+	}
+
+	if err := loginThrottle.Store.Reset(ctx, helpers.AccountAttemptKey(user.Email)); err != nil {
+		return fmt.Errorf("deleting login attempts: %w", err)
+	}
+
+	if _, err := UserCollection.DeleteOne(ctx, bson.M{"_id": user.User_ID}); err != nil {
+		return fmt.Errorf("deleting user: %w", err)
+	}
+	return nil
+}
+
+// StartAccountPurger deletes accounts whose grace period has ended, checking every accountPurgeInterval until ctx is done
+func StartAccountPurger(ctx context.Context) {
+	ticker := time.NewTicker(accountPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purgeScheduledAccounts(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purgeScheduledAccounts(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	cursor, err := UserCollection.Find(ctx, bson.M{"deletion_scheduled": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		log.Printf("Error finding accounts to delete: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			log.Printf("Error decoding account to delete: %v", err)
+			continue
+		}
+		if err := purgeUser(ctx, user); err != nil {
+			log.Printf("Error deleting account %s: %v", user.User_ID.Hex(), err)
+			continue
+		}
+		log.Printf("Deleted account %s after its grace period", user.User_ID.Hex())
+	}
+}
+
+// confirmAccountOwner checks the password and, when enabled, the second factor before destructive account changes.
+// Users who only sign in through a provider have no password to confirm.
+func confirmAccountOwner(ctx context.Context, c *gin.Context, user models.User, password string, code string) bool {
+	if user.Password != "" && !helpers.CheckPassword(user.Password, password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Password is incorrect"})
+		return false
+	}
+
+	if user.TOTP_Enabled {
+		valid, err := verifySecondFactor(ctx, user, code)
+		if err != nil {
+			log.Printf("Error verifying second factor: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+			return false
+		}
+		if !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid authentication code"})
+			return false
+		}
+	}
+	return true
+}
+
+func RequestAccountDeletion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var deletionData struct {
+			Password string `json:"password"`
+			Code     string `json:"code"`
+		}
+		if err := c.BindJSON(&deletionData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		user, ok := currentUser(ctx, c)
+		if !ok {
+			return
+		}
+
+		if user.Deletion_Scheduled != nil {
+			c.JSON(http.StatusConflict, gin.H{"success": false, "error": "Account deletion is already scheduled", "deletion_scheduled": user.Deletion_Scheduled})
+			return
+		}
+
+		if !confirmAccountOwner(ctx, c, user, deletionData.Password, deletionData.Code) {
+			return
+		}
+
+		scheduledAt := time.Now().Add(accountDeletionGracePeriod())
+		_, err := UserCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": user.User_ID},
+			bson.M{"$set": bson.M{"deletion_scheduled": scheduledAt, "updated_at": time.Now()}},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error scheduling account deletion"})
+			return
+		}
+
+		body := fmt.Sprintf(
+			"<p>Your account is scheduled to be deleted on %s.</p><p>If you did not request this, sign in and cancel the deletion before then.</p>",
+			scheduledAt.Format("2 January 2006 15:04 MST"),
+		)
+		if err := SendEmailTo(user.Email, "Your account is scheduled for deletion", body); err != nil {
+			log.Printf("Error sending account deletion email: %v", err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":            true,
+			"message":            "Account deletion scheduled",
+			"deletion_scheduled": scheduledAt,
+		})
+	}
+}
+
+func CancelAccountDeletion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		user, ok := currentUser(ctx, c)
+		if !ok {
+			return
+		}
+
+		if user.Deletion_Scheduled == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Account deletion is not scheduled"})
+			return
+		}
+
+		_, err := UserCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": user.User_ID},
+			bson.M{
+				"$set":   bson.M{"updated_at": time.Now()},
+				"$unset": bson.M{"deletion_scheduled": ""},
+			},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error cancelling account deletion"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Account deletion cancelled"})
+	}
+}
+
+// ExportAccountData responds with a ZIP of JSON files holding everything stored about the user
+func ExportAccountData() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		user, ok := currentUser(ctx, c)
+		if !ok {
+			return
+		}
+		userID := user.User_ID.Hex()
+
+		var categories []models.ExpenseCategory
+		var items []models.ExpenseItem
+		var sessions []models.Session
+		var apiKeys []models.ApiKey
+
+		exports := []struct {
+			collection *mongo.Collection
+			results    interface{}
+		}{
+			{ExpenseCategoryCollection, &categories},
+			{ExpenseItemCollection, &items},
+			{SessionCollection, &sessions},
+			{ApiKeyCollection, &apiKeys},
+		}
+		for _, export := range exports {
+			cursor, err := export.collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.M{"created_at": 1}))
+			if err != nil {
+				log.Printf("Error exporting %s: %v", export.collection.Name(), err)
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error exporting account data"})
+				return
+			}
+			if err := cursor.All(ctx, export.results); err != nil {
+				log.Printf("Error exporting %s: %v", export.collection.Name(), err)
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error exporting account data"})
+				return
+			}
+		}
+
+		files := []struct {
+			name string
+			data interface{}
+		}{
+			{"profile.json", models.NewUserResponse(user)},
+			{"expense_categories.json", categories},
+			{"expense_items.json", items},
+			{"sessions.json", sessions},
+			{"api_keys.json", apiKeys},
+		}
+
+		fileName := fmt.Sprintf("account-export-%s.zip", time.Now().Format("2006-01-02"))
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+		c.Status(http.StatusOK)
+
+		archive := zip.NewWriter(c.Writer)
+		for _, file := range files {
+			writer, err := archive.Create(file.name)
+			if err != nil {
+				log.Printf("Error writing export archive: %v", err)
+				return
+			}
+			encoder := json.NewEncoder(writer)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(file.data); err != nil {
+				log.Printf("Error writing export archive: %v", err)
+				return
+			}
+		}
+		if err := archive.Close(); err != nil {
+			log.Printf("Error writing export archive: %v", err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"testing"
+
+	"portfolio/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUserRecordScrubs(t *testing.T) {
+	user := models.User{User_ID: primitive.NewObjectID()}
+	userID := user.User_ID.Hex()
+
+	scrubs := map[string]recordScrub{}
+	for _, scrub := range userRecordScrubs(user) {
+		scrubs[scrub.collection.Name()] = scrub
+	}
+
+	invites, ok := scrubs["Invites"]
+	if !ok {
+		t.Fatal("invite redemptions are not scrubbed")
+	}
+	if invites.filter["used_by.user_id"] != userID {
+		t.Errorf("invite filter = %v", invites.filter)
+	}
+	pull, _ := invites.update["$pull"].(bson.M)
+	if used, _ := pull["used_by"].(bson.M); used["user_id"] != userID {
+		t.Errorf("invite update = %v", invites.update)
+	}
+
+	audit, ok := scrubs["AuditLogs"]
+	if !ok {
+		t.Fatal("audit entries are not scrubbed")
+	}
+	if audit.filter["actor_id"] != userID {
+		t.Errorf("audit filter = %v", audit.filter)
+	}
+	if set, _ := audit.update["$set"].(bson.M); set["actor_email"] != "" {
+		t.Errorf("audit update = %v", audit.update)
+	}
+}
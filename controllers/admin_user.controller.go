@@ -0,0 +1,310 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"portfolio/models"
+	token "portfolio/tokens"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// paginationParams reads the page (from 1) and limit query parameters
+func paginationParams(c *gin.Context) (page int, limit int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err = strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return page, limit
+}
+
+// GetAllUsers searches users by name or email (q), role and suspended status, a page at a time
+func GetAllUsers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{}
+		if query := c.Query("q"); query != "" {
+			pattern := primitive.Regex{Pattern: regexp.QuoteMeta(query), Options: "i"}
+			filter["$or"] = bson.A{bson.M{"name": pattern}, bson.M{"email": pattern}}
+		}
+		if role := c.Query("role"); role != "" {
+			filter["role"] = role
+		}
+		if suspended, err := strconv.ParseBool(c.Query("suspended")); err == nil {
+			filter["suspended"] = suspended
+		}
+
+		page, limit := paginationParams(c)
+		opts := options.Find().
+			SetSort(bson.M{"created_at": -1}).
+			SetSkip(int64((page - 1) * limit)).
+			SetLimit(int64(limit))
+
+		total, err := UserCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			log.Printf("Error counting users: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving users"})
+			return
+		}
+
+		cursor, err := UserCollection.Find(ctx, filter, opts)
+		if err != nil {
+			log.Printf("Error finding users: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving users"})
+			return
+		}
+
+		var users []models.User
+		if err := cursor.All(ctx, &users); err != nil {
+			log.Printf("Error decoding users: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error decoding users"})
+			return
+		}
+
+		userResponses := make([]models.UserResponse, 0, len(users))
+		for _, user := range users {
+			userResponses = append(userResponses, models.NewUserResponse(user))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Users retrieved successfully",
+			"users":   userResponses,
+			"total":   total,
+			"page":    page,
+			"limit":   limit,
+		})
+	}
+}
+
+// findTargetUser loads the user named by the :id path parameter
+func findTargetUser(ctx context.Context, c *gin.Context) (models.User, bool) {
+	var user models.User
+
+	objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid user ID"})
+		return user, false
+	}
+
+	if err := UserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "User not found"})
+		return user, false
+	}
+	return user, true
+}
+
+func GetUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		user, ok := findTargetUser(ctx, c)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "user": models.NewUserResponse(user)})
+	}
+}
+
+func SuspendUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var suspendData struct {
+			Reason string `json:"reason"`
+		}
+		if err := c.BindJSON(&suspendData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		user, ok := findTargetUser(ctx, c)
+		if !ok {
+			return
+		}
+		if user.User_ID.Hex() == c.GetString("userId") {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "You cannot suspend your own account"})
+			return
+		}
+		if !canManageUser(c, user, nil) {
+			return
+		}
+
+		now := time.Now()
+		_, err := UserCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": user.User_ID},
+			bson.M{"$set": bson.M{
+				"suspended":         true,
+				"suspended_at":      now,
+				"suspension_reason": suspendData.Reason,
+				"updated_at":        now,
+			}},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error suspending user"})
+			return
+		}
+
+		// Signing out everywhere stops refresh tokens too; access tokens are rejected by the middleware
+		if err := revokeSessions(ctx, bson.M{"user_id": user.User_ID.Hex()}); err != nil {
+			log.Printf("Error revoking sessions: %v", err)
+		}
+		recordAudit(ctx, c, models.AuditUserSuspend, user.User_ID.Hex(), suspendData.Reason, "")
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "User suspended successfully"})
+	}
+}
+
+func ReactivateUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		user, ok := findTargetUser(ctx, c)
+		if !ok {
+			return
+		}
+		if !canManageUser(c, user, nil) {
+			return
+		}
+
+		_, err := UserCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": user.User_ID},
+			bson.M{
+				"$set":   bson.M{"suspended": false, "updated_at": time.Now()},
+				"$unset": bson.M{"suspended_at": "", "suspension_reason": ""},
+			},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error reactivating user"})
+			return
+		}
+		recordAudit(ctx, c, models.AuditUserReactivate, user.User_ID.Hex(), "", "")
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "User reactivated successfully"})
+	}
+}
+
+// StopImpersonation ends the impersonation session the request is made with, so its access token stops working
+// before it expires
+func StopImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		impersonatorID := c.GetString("impersonatorId")
+		if impersonatorID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "This session is not impersonating a user"})
+			return
+		}
+
+		if err := revokeSessions(ctx, bson.M{"_id": sessionObjectID(c.GetString("sessionId"))}); err != nil {
+			log.Printf("Error ending impersonation: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error ending impersonation"})
+			return
+		}
+
+		_, err := AuditLogCollection.InsertOne(ctx, models.AuditLog{
+			Audit_ID:   primitive.NewObjectID(),
+			Action:     models.AuditImpersonationStop,
+			Actor_ID:   impersonatorID,
+			Target_ID:  c.GetString("userId"),
+			IP:         c.ClientIP(),
+			Created_At: time.Now(),
+		})
+		if err != nil {
+			log.Printf("Error writing audit log: %v", err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Impersonation stopped"})
+	}
+}
+
+// ImpersonateUser issues a short-lived access token acting as the user so support staff can see what they see.
+// There is no refresh token, the session is marked with the impersonator, and every change made with it is audited.
+func ImpersonateUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var impersonateData struct {
+			Reason string `json:"reason" binding:"required"`
+		}
+		if err := c.BindJSON(&impersonateData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "A reason is required to impersonate a user"})
+			return
+		}
+
+		if c.GetString("impersonatorId") != "" || c.GetString("apiKeyId") != "" {
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "Impersonation requires your own login"})
+			return
+		}
+
+		user, ok := findTargetUser(ctx, c)
+		if !ok {
+			return
+		}
+		if user.User_ID.Hex() == c.GetString("userId") {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "You cannot impersonate yourself"})
+			return
+		}
+		if user.Suspended {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Suspended users cannot be impersonated"})
+			return
+		}
+		if !canManageUser(c, user, nil) {
+			return
+		}
+
+		session := newSession(c, user.User_ID.Hex())
+		session.Impersonator_ID = c.GetString("userId")
+		if _, err := SessionCollection.InsertOne(ctx, session); err != nil {
+			log.Printf("Error creating session: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating session"})
+			return
+		}
+
+		accessToken, err := token.TokenGenerator(user.Email, user.User_ID.Hex(), user.Role, session.Session_ID.Hex())
+		if err != nil {
+			log.Printf("Error generating token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error generating token"})
+			return
+		}
+		recordAudit(ctx, c, models.AuditImpersonationStart, user.User_ID.Hex(), impersonateData.Reason, "")
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":     true,
+			"message":     "Impersonation started",
+			"accessToken": accessToken,
+			"expires_in":  int(token.AccessTokenTTL().Seconds()),
+			"user":        models.NewUserResponse(user),
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"portfolio/database"
+	"portfolio/helpers"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ApiKeyCollection *mongo.Collection = database.PortfolioData(database.Client, "ApiKeys")
+
+// generateApiKey returns a new key as pk_<prefix>_<secret>; the prefix is stored so keys can be recognised in lists
+func generateApiKey() (key string, prefix string, err error) {
+	prefix, err = helpers.GenerateRandomToken(6)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return helpers.APIKeyPrefix + prefix + "_" + secret, prefix, nil
+}
+
+func CreateApiKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userId, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Unauthorized"})
+			return
+		}
+
+		var keyData struct {
+			Name          string     `json:"name"`
+			Permissions   []string   `json:"permissions"`
+			Expires_At    *time.Time `json:"expires_at"`
+			ExpiresInDays int        `json:"expires_in_days"`
+		}
+		if err := c.BindJSON(&keyData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		if keyData.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Name is required"})
+			return
+		}
+		if len(keyData.Permissions) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "At least one permission is required"})
+			return
+		}
+		// A key can only be scoped to permissions the owner's role already has
+		if !bindRolePermissions(c, keyData.Permissions) {
+			return
+		}
+
+		expiresAt := keyData.Expires_At
+		if expiresAt == nil && keyData.ExpiresInDays > 0 {
+			expiry := time.Now().AddDate(0, 0, keyData.ExpiresInDays)
+			expiresAt = &expiry
+		}
+		if expiresAt != nil && expiresAt.Before(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Expiry must be in the future"})
+			return
+		}
+
+		key, prefix, err := generateApiKey()
+		if err != nil {
+			log.Printf("Error generating API key: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating API key"})
+			return
+		}
+
+		apiKey := models.ApiKey{
+			Key_ID:      primitive.NewObjectID(),
+			User_ID:     userId.(string),
+			Name:        keyData.Name,
+			Prefix:      prefix,
+			Key_Hash:    helpers.HashToken(key),
+			Permissions: keyData.Permissions,
+			Expires_At:  expiresAt,
+			Created_At:  time.Now(),
+		}
+
+		if _, err := ApiKeyCollection.InsertOne(ctx, apiKey); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating API key"})
+			return
+		}
+
+		// The key itself is only returned here; afterwards only its hash is kept
+		c.JSON(http.StatusCreated, gin.H{"success": true, "message": "API key created successfully", "key": key, "apiKey": apiKey})
+	}
+}
+
+func GetAllApiKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userId, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Unauthorized"})
+			return
+		}
+
+		apiKeys := []models.ApiKey{}
+		cursor, err := ApiKeyCollection.Find(ctx, bson.M{"user_id": userId.(string)}, options.Find().SetSort(bson.M{"created_at": -1}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving API keys"})
+			return
+		}
+
+		if err = cursor.All(ctx, &apiKeys); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error decoding API keys"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "apiKeys": apiKeys})
+	}
+}
+
+func DeleteApiKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		userId, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Unauthorized"})
+			return
+		}
+
+		keyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid API key ID"})
+			return
+		}
+
+		result, err := ApiKeyCollection.DeleteOne(ctx, bson.M{"_id": keyID, "user_id": userId.(string)})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error deleting API key"})
+			return
+		}
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "API key not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "API key deleted successfully"})
+	}
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"portfolio/database"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var AuditLogCollection *mongo.Collection = database.PortfolioData(database.Client, "AuditLogs")
+
+// recordAudit stores an administrative action taken by the caller. Failures are logged rather than
+// failing the request, since the action itself has already happened.
+func recordAudit(ctx context.Context, c *gin.Context, action string, targetID string, reason string, details string) {
+	_, err := AuditLogCollection.InsertOne(ctx, models.AuditLog{
+		Audit_ID:    primitive.NewObjectID(),
+		Action:      action,
+		Actor_ID:    c.GetString("userId"),
+		Actor_Email: c.GetString("email"),
+		Target_ID:   targetID,
+		Reason:      reason,
+		Details:     details,
+		IP:          c.ClientIP(),
+		Created_At:  time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error writing audit log: %v", err)
+	}
+}
+
+func GetAuditLogs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{}
+		if action := c.Query("action"); action != "" {
+			filter["action"] = action
+		}
+		if actorID := c.Query("actor_id"); actorID != "" {
+			filter["actor_id"] = actorID
+		}
+		if targetID := c.Query("target_id"); targetID != "" {
+			filter["target_id"] = targetID
+		}
+
+		page, limit := paginationParams(c)
+		opts := options.Find().
+			SetSort(bson.M{"created_at": -1}).
+			SetSkip(int64((page - 1) * limit)).
+			SetLimit(int64(limit))
+
+		total, err := AuditLogCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving audit logs"})
+			return
+		}
+
+		auditLogs := []models.AuditLog{}
+		cursor, err := AuditLogCollection.Find(ctx, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving audit logs"})
+			return
+		}
+		if err = cursor.All(ctx, &auditLogs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error decoding audit logs"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":   true,
+			"auditLogs": auditLogs,
+			"total":     total,
+			"page":      page,
+			"limit":     limit,
+		})
+	}
+}
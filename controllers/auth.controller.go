@@ -1,18 +1,24 @@
 package controllers
 
 import (
+	"context"
+	"log"
 	"net/http"
-	"os"
+	"portfolio/helpers"
 	"portfolio/models"
 	generate "portfolio/tokens"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func Login() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		EMAIL := os.Getenv("EMAIL")
-		PASSWORD := os.Getenv("PASSWORD")
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
 
 		var loginDetails models.LoginDetails
 		if err := c.BindJSON(&loginDetails); err != nil {
@@ -20,15 +26,123 @@ func Login() gin.HandlerFunc {
 			return
 		}
 
-		if loginDetails.Email == EMAIL && loginDetails.Password == PASSWORD {
-			token, err := generate.TokenGenerator(loginDetails.Email, "", 0)
+		if !allowLoginAttempt(ctx, c, loginDetails.Email) {
+			return
+		}
+
+		var admin models.User
+		err := UserCollection.FindOne(ctx, bson.M{"email": loginDetails.Email, "role": models.RolePortfolioAdmin}).Decode(&admin)
+		if err != nil && err != mongo.ErrNoDocuments {
+			log.Printf("Error finding admin: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+			return
+		}
+		if err == mongo.ErrNoDocuments {
+			checkPasswordForUnknownUser(loginDetails.Password)
+			rejectLogin(ctx, c, loginDetails.Email)
+			return
+		}
+		if !helpers.CheckPassword(admin.Password, loginDetails.Password) {
+			rejectLogin(ctx, c, loginDetails.Email)
+			return
+		}
+		upgradePasswordHash(ctx, admin, loginDetails.Password)
+
+		if admin.Suspended {
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "Account is suspended"})
+			return
+		}
+
+		if admin.TOTP_Enabled {
+			challengeToken, err := generate.ChallengeTokenGenerator(admin.Email, admin.User_ID.Hex(), admin.Role)
 			if err != nil {
+				log.Printf("Error generating challenge token: %v", err)
 				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to generate token"})
 				return
 			}
-			c.JSON(http.StatusOK, gin.H{"success": true, "token": token})
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid email or password"})
+			c.JSON(http.StatusOK, gin.H{"success": true, "two_factor_required": true, "challengeToken": challengeToken})
+			return
 		}
+
+		completeAdminLogin(ctx, c, admin)
+	}
+}
+
+// VerifyAdminTwoFactor exchanges a portfolio admin's challenge token and authentication code for a full token
+func VerifyAdminTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var twoFactorData struct {
+			ChallengeToken string `json:"challenge_token" binding:"required"`
+			Code           string `json:"code" binding:"required"`
+		}
+		if err := c.BindJSON(&twoFactorData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		claims, msg := generate.ValidateChallengeToken(twoFactorData.ChallengeToken)
+		if msg != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid or expired challenge token"})
+			return
+		}
+
+		objID, err := primitive.ObjectIDFromHex(claims.User_ID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid or expired challenge token"})
+			return
+		}
+
+		var admin models.User
+		err = UserCollection.FindOne(ctx, bson.M{"_id": objID, "role": models.RolePortfolioAdmin}).Decode(&admin)
+		if err != nil || !admin.TOTP_Enabled {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid or expired challenge token"})
+			return
+		}
+
+		if !allowLoginAttempt(ctx, c, admin.Email) {
+			return
+		}
+
+		valid, err := verifySecondFactor(ctx, admin, twoFactorData.Code)
+		if err != nil {
+			log.Printf("Error verifying second factor: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+			return
+		}
+		if !valid {
+			if err := loginThrottle.RecordFailure(ctx, admin.Email, c.ClientIP()); err != nil {
+				log.Printf("Error recording failed login: %v", err)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid authentication code"})
+			return
+		}
+
+		if !completeChallenge(ctx, c, claims) {
+			return
+		}
+		completeAdminLogin(ctx, c, admin)
+	}
+}
+
+// completeAdminLogin starts a session for a portfolio admin and responds with their tokens
+func completeAdminLogin(ctx context.Context, c *gin.Context, admin models.User) {
+	recordLoginSuccess(ctx, admin.Email)
+
+	session, err := createSession(ctx, c, admin.User_ID.Hex())
+	if err != nil {
+		log.Printf("Error creating session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating session"})
+		return
+	}
+
+	token, refreshToken, err := issueTokenPair(ctx, ClientPortfolio, session.Session_ID.Hex(), admin.Email, admin.User_ID.Hex(), admin.Role)
+	if err != nil {
+		log.Printf("Error generating token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Failed to generate token"})
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "token": token, "refreshToken": refreshToken})
 }
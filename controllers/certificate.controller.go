@@ -28,6 +28,15 @@ func CreateCertificate() gin.HandlerFunc {
 			return
 		}
 
+		status, ok := bindPublishStatus(c, certificate.Status, certificate.Publish_At)
+		if !ok {
+			return
+		}
+		certificate.Status = &status
+		if !validTranslations(c, certificate.Translations, certificateTranslatable) {
+			return
+		}
+
 		certificate.Certificate_ID = primitive.NewObjectID()
 		certificate.Created_At = time.Now()
 		certificate.Updated_At = time.Now()
@@ -62,17 +71,31 @@ func UpdateCertificate() gin.HandlerFunc {
 
 		certificate.Updated_At = time.Now()
 
-		update := bson.M{
-			"$set": bson.M{
-				"title":      certificate.Title,
-				"content":    certificate.Content,
-				"image":      certificate.Image,
-				"demo_link":  certificate.DemoLink,
-				"t1":         certificate.T1,
-				"t2":         certificate.T2,
-				"updated_at": certificate.Updated_At,
-			},
+		fields := bson.M{
+			"title":      certificate.Title,
+			"content":    certificate.Content,
+			"image":      certificate.Image,
+			"demo_link":  certificate.DemoLink,
+			"t1":         certificate.T1,
+			"t2":         certificate.T2,
+			"updated_at": certificate.Updated_At,
+		}
+		// Translations left out of the request are kept
+		if certificate.Translations != nil {
+			if !validTranslations(c, certificate.Translations, certificateTranslatable) {
+				return
+			}
+			fields["translations"] = certificate.Translations
+		}
+		if certificate.Status != nil {
+			status, ok := bindPublishStatus(c, certificate.Status, certificate.Publish_At)
+			if !ok {
+				return
+			}
+			fields["status"] = status
+			fields["publish_at"] = certificate.Publish_At
 		}
+		update := bson.M{"$set": fields}
 
 		result, err := CertificateCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
 		if err != nil {
@@ -122,7 +145,7 @@ func GetAllCertificates() gin.HandlerFunc {
 		defer cancel()
 
 		var certificates []models.Certificate
-		cursor, err := CertificateCollection.Find(ctx, bson.M{})
+		cursor, err := CertificateCollection.Find(ctx, visibleContentFilter(c, bson.M{}))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving certificates"})
 			return
@@ -133,6 +156,11 @@ func GetAllCertificates() gin.HandlerFunc {
 			return
 		}
 
+		locale := requestLocale(c)
+		for i := range certificates {
+			localize(locale, &certificates[i], &certificates[i].Translations)
+		}
+
 		c.JSON(http.StatusOK, gin.H{"success": true, "certificates": certificates})
 	}
 }
@@ -150,13 +178,19 @@ func GetOneCertificate() gin.HandlerFunc {
 		defer cancel()
 
 		var certificate models.Certificate
-		err = CertificateCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&certificate)
+		err = CertificateCollection.FindOne(ctx, visibleContentFilter(c, bson.M{"_id": objID})).Decode(&certificate)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Certificate not found"})
+			return
+		}
 		if err != nil {
 			log.Printf("Error retrieving certificate: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving certificate", "details": err.Error()})
 			return
 		}
 
+		localize(requestLocale(c), &certificate, &certificate.Translations)
+
 		c.JSON(http.StatusOK, gin.H{"success": true, "certificate": certificate})
 	}
 }
@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"portfolio/database"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var EducationCollection *mongo.Collection = database.PortfolioData(database.Client, "Education")
+
+// bindEducation binds and validates an education entry from a create or update request
+func bindEducation(c *gin.Context, education *models.Education) bool {
+	if err := c.BindJSON(education); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return false
+	}
+
+	education.Institution = strings.TrimSpace(education.Institution)
+	if education.Institution == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Institution is required"})
+		return false
+	}
+	if err := checkDateRange(education.Start_Date, education.End_Date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return false
+	}
+	education.Highlights = normalizeHighlights(education.Highlights)
+	return validTranslations(c, education.Translations, educationTranslatable)
+}
+
+func CreateEducation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var education models.Education
+		if !bindEducation(c, &education) {
+			return
+		}
+
+		status, ok := bindPublishStatus(c, education.Status, education.Publish_At)
+		if !ok {
+			return
+		}
+		education.Status = &status
+
+		education.Education_ID = primitive.NewObjectID()
+		education.Created_At = time.Now()
+		education.Updated_At = time.Now()
+
+		_, err := EducationCollection.InsertOne(ctx, education)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating education"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Education created successfully!", "education": education})
+	}
+}
+
+func UpdateEducation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		educationID := c.Param("id")
+		objID, err := primitive.ObjectIDFromHex(educationID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid education ID"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var education models.Education
+		if !bindEducation(c, &education) {
+			return
+		}
+		education.Updated_At = time.Now()
+
+		fields := bson.M{
+			"institution": education.Institution,
+			"degree":      education.Degree,
+			"field":       education.Field,
+			"location":    education.Location,
+			"start_date":  education.Start_Date,
+			"end_date":    education.End_Date,
+			"grade":       education.Grade,
+			"description": education.Description,
+			"highlights":  education.Highlights,
+			"sort_order":  education.Sort_Order,
+			"updated_at":  education.Updated_At,
+		}
+		// Translations left out of the request are kept
+		if education.Translations != nil {
+			fields["translations"] = education.Translations
+		}
+		if education.Status != nil {
+			status, ok := bindPublishStatus(c, education.Status, education.Publish_At)
+			if !ok {
+				return
+			}
+			fields["status"] = status
+			fields["publish_at"] = education.Publish_At
+		}
+		update := bson.M{"$set": fields}
+
+		result, err := EducationCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error updating education", "details": err.Error()})
+			return
+		}
+
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Education not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Education updated successfully"})
+	}
+}
+
+func DeleteEducation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		educationID := c.Param("id")
+		objID, err := primitive.ObjectIDFromHex(educationID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid education ID"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		result, err := EducationCollection.DeleteOne(ctx, bson.M{"_id": objID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error deleting education"})
+			return
+		}
+
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Education not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Education deleted successfully"})
+	}
+}
+
+// GetAllEducation lists education in display order
+func GetAllEducation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		education, err := findEducation(ctx, c)
+		if err != nil {
+			log.Printf("Error retrieving education: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving education"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "education": education})
+	}
+}
+
+func GetOneEducation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		educationID := c.Param("id")
+		objID, err := primitive.ObjectIDFromHex(educationID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid education ID"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var education models.Education
+		err = EducationCollection.FindOne(ctx, visibleContentFilter(c, bson.M{"_id": objID})).Decode(&education)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Education not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("Error retrieving education: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving education"})
+			return
+		}
+
+		localize(requestLocale(c), &education, &education.Translations)
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "education": education})
+	}
+}
@@ -22,11 +22,15 @@ import (
 var EmailCollection *mongo.Collection = database.PortfolioData(database.Client, "Emails")
 
 func SendEmail(subject string, body string) error {
+	return SendEmailTo(os.Getenv("SMIP_RECEPT_MAIL"), subject, body)
+}
+
+// SendEmailTo sends an HTML email to the given recipient
+func SendEmailTo(to string, subject string, body string) error {
 	SMIP_HOST := os.Getenv("SMIP_HOST")
 	SMIP_PORT, portErr := strconv.Atoi(os.Getenv("SMIP_PORT"))
 	SMIP_MAIL := os.Getenv("SMIP_MAIL")
 	SMIP_PASSWORD := os.Getenv("SMIP_PASSWORD")
-	SMIP_RECEPT_MAIL := os.Getenv("SMIP_RECEPT_MAIL")
 
 	if portErr != nil {
 		log.Printf("Error converting SMTP_PORT to integer: %v", portErr)
@@ -35,7 +39,7 @@ func SendEmail(subject string, body string) error {
 
 	m := gomail.NewMessage()
 	m.SetHeader("From", SMIP_MAIL)
-	m.SetHeader("To", SMIP_RECEPT_MAIL)
+	m.SetHeader("To", to)
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/html", body)
 
@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"portfolio/database"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ExperienceCollection *mongo.Collection = database.PortfolioData(database.Client, "Experience")
+
+// bindExperience binds and validates an experience from a create or update request
+func bindExperience(c *gin.Context, experience *models.Experience) bool {
+	if err := c.BindJSON(experience); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return false
+	}
+
+	experience.Company = strings.TrimSpace(experience.Company)
+	experience.Role = strings.TrimSpace(experience.Role)
+	if experience.Company == "" || experience.Role == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Company and role are required"})
+		return false
+	}
+	if experience.Company_URL != "" && !isWebURL(experience.Company_URL) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "company_url must be an http or https URL"})
+		return false
+	}
+	if err := checkDateRange(experience.Start_Date, experience.End_Date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return false
+	}
+	experience.Highlights = normalizeHighlights(experience.Highlights)
+	return validTranslations(c, experience.Translations, experienceTranslatable)
+}
+
+func CreateExperience() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var experience models.Experience
+		if !bindExperience(c, &experience) {
+			return
+		}
+
+		status, ok := bindPublishStatus(c, experience.Status, experience.Publish_At)
+		if !ok {
+			return
+		}
+		experience.Status = &status
+
+		experience.Experience_ID = primitive.NewObjectID()
+		experience.Created_At = time.Now()
+		experience.Updated_At = time.Now()
+
+		_, err := ExperienceCollection.InsertOne(ctx, experience)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating experience"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Experience created successfully!", "experience": experience})
+	}
+}
+
+func UpdateExperience() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		experienceID := c.Param("id")
+		objID, err := primitive.ObjectIDFromHex(experienceID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid experience ID"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var experience models.Experience
+		if !bindExperience(c, &experience) {
+			return
+		}
+		experience.Updated_At = time.Now()
+
+		fields := bson.M{
+			"company":     experience.Company,
+			"company_url": experience.Company_URL,
+			"role":        experience.Role,
+			"location":    experience.Location,
+			"start_date":  experience.Start_Date,
+			"end_date":    experience.End_Date,
+			"description": experience.Description,
+			"highlights":  experience.Highlights,
+			"sort_order":  experience.Sort_Order,
+			"updated_at":  experience.Updated_At,
+		}
+		// Translations left out of the request are kept
+		if experience.Translations != nil {
+			fields["translations"] = experience.Translations
+		}
+		if experience.Status != nil {
+			status, ok := bindPublishStatus(c, experience.Status, experience.Publish_At)
+			if !ok {
+				return
+			}
+			fields["status"] = status
+			fields["publish_at"] = experience.Publish_At
+		}
+		update := bson.M{"$set": fields}
+
+		result, err := ExperienceCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error updating experience", "details": err.Error()})
+			return
+		}
+
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Experience not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Experience updated successfully"})
+	}
+}
+
+func DeleteExperience() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		experienceID := c.Param("id")
+		objID, err := primitive.ObjectIDFromHex(experienceID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid experience ID"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		result, err := ExperienceCollection.DeleteOne(ctx, bson.M{"_id": objID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error deleting experience"})
+			return
+		}
+
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Experience not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Experience deleted successfully"})
+	}
+}
+
+// GetAllExperience lists experience in display order
+func GetAllExperience() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		experience, err := findExperience(ctx, c)
+		if err != nil {
+			log.Printf("Error retrieving experience: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving experience"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "experience": experience})
+	}
+}
+
+func GetOneExperience() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		experienceID := c.Param("id")
+		objID, err := primitive.ObjectIDFromHex(experienceID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid experience ID"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var experience models.Experience
+		err = ExperienceCollection.FindOne(ctx, visibleContentFilter(c, bson.M{"_id": objID})).Decode(&experience)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Experience not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("Error retrieving experience: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving experience"})
+			return
+		}
+
+		localize(requestLocale(c), &experience, &experience.Translations)
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "experience": experience})
+	}
+}
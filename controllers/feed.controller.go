@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"portfolio/helpers"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	feedTitle       = "Kyaw Swar Lynn"
+	feedDescription = "Latest posts and projects"
+	// feedSize is the number of most recent items a feed lists
+	feedSize = 50
+)
+
+// portfolioURL is the public address of the portfolio frontend, without a trailing slash
+func portfolioURL() string {
+	return strings.TrimRight(os.Getenv("PORTFOLIO_URL"), "/")
+}
+
+// ValidatePublicURLs checks at startup that PORTFOLIO_URL, and API_URL when set, are absolute http(s) URLs.
+// Feeds and sitemaps are useless to crawlers and readers with relative links.
+func ValidatePublicURLs() error {
+	if os.Getenv("PORTFOLIO_URL") == "" {
+		return errors.New("PORTFOLIO_URL must be set")
+	}
+	if err := checkAbsoluteURL(os.Getenv("PORTFOLIO_URL")); err != nil {
+		return fmt.Errorf("PORTFOLIO_URL: %w", err)
+	}
+	if value := os.Getenv("API_URL"); value != "" {
+		if err := checkAbsoluteURL(value); err != nil {
+			return fmt.Errorf("API_URL: %w", err)
+		}
+	}
+	return nil
+}
+
+func checkAbsoluteURL(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%q is not an absolute http or https URL", value)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return fmt.Errorf("%q must not have a query or fragment", value)
+	}
+	return nil
+}
+
+// apiURL is the public address of this API, read from API_URL. It defaults to PORTFOLIO_URL for a frontend that
+// proxies the API under its own host.
+func apiURL() string {
+	if value := strings.TrimRight(os.Getenv("API_URL"), "/"); value != "" {
+		return value
+	}
+	return portfolioURL()
+}
+
+func postURL(post models.Post) string {
+	return portfolioURL() + postPath(post.Slug)
+}
+
+func projectURL(project models.Project) string {
+	return portfolioURL() + projectPath(project.Project_ID)
+}
+
+// loadFeed collects the latest published posts and projects, newest first
+func loadFeed(ctx context.Context, feedURL string) (helpers.Feed, error) {
+	feed := helpers.Feed{
+		Title:       feedTitle,
+		Description: feedDescription,
+		Author:      feedTitle,
+		HomeURL:     portfolioURL(),
+		FeedURL:     feedURL,
+	}
+
+	var posts []models.Post
+	cursor, err := PostCollection.Find(ctx, publishedPostFilter(),
+		options.Find().SetSort(bson.D{{Key: "published_at", Value: -1}}).SetLimit(feedSize))
+	if err != nil {
+		return feed, err
+	}
+	if err := cursor.All(ctx, &posts); err != nil {
+		return feed, err
+	}
+
+	for _, post := range posts {
+		published := post.Created_At
+		if post.Published_At != nil {
+			published = *post.Published_At
+		}
+		feed.Items = append(feed.Items, helpers.FeedItem{
+			ID:          postURL(post),
+			URL:         postURL(post),
+			Title:       post.Title,
+			Summary:     post.Excerpt,
+			ContentHTML: post.Body_HTML,
+			Image:       post.Cover_Image,
+			Tags:        post.Tags,
+			Published:   published,
+			Updated:     post.Updated_At,
+		})
+	}
+
+	var projects []models.Project
+	cursor, err = ProjectCollection.Find(ctx, publishedContentFilter(),
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(feedSize))
+	if err != nil {
+		return feed, err
+	}
+	if err := cursor.All(ctx, &projects); err != nil {
+		return feed, err
+	}
+
+	for _, project := range projects {
+		published := project.Created_At
+		if project.Publish_At != nil {
+			published = *project.Publish_At
+		}
+		item := helpers.FeedItem{
+			ID:        projectURL(project),
+			URL:       projectURL(project),
+			Title:     stringValue(project.Title),
+			Summary:   stringValue(project.Description),
+			Image:     stringValue(project.Image),
+			Tags:      project.Tags,
+			Published: published,
+			Updated:   project.Updated_At,
+		}
+		if len(item.Tags) == 0 && project.Tag != nil && *project.Tag != "" {
+			item.Tags = []string{*project.Tag}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	sort.SliceStable(feed.Items, func(i, j int) bool {
+		return feed.Items[i].Published.After(feed.Items[j].Published)
+	})
+	if len(feed.Items) > feedSize {
+		feed.Items = feed.Items[:feedSize]
+	}
+
+	for _, item := range feed.Items {
+		if item.Updated.After(feed.Updated) {
+			feed.Updated = item.Updated
+		}
+	}
+	return feed, nil
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// serveWithValidators writes a generated document with an ETag and Last-Modified, answering conditional
+// requests with 304 Not Modified
+func serveWithValidators(c *gin.Context, contentType string, body []byte, lastModified time.Time) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=300")
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				c.Status(http.StatusNotModified)
+				return
+			}
+		}
+	} else if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !lastModified.IsZero() {
+		if !lastModified.Truncate(time.Second).After(since) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// feedURL is the feed's public address. It is built from configuration and the matched route, never from the
+// Host or X-Forwarded-Proto headers, because the feed is cached publicly and its address doubles as its ID.
+func feedURL(c *gin.Context) string {
+	return apiURL() + c.FullPath()
+}
+
+// feedHandler builds the feed and renders it with render
+func feedHandler(contentType string, render func(helpers.Feed) ([]byte, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		feed, err := loadFeed(ctx, feedURL(c))
+		if err != nil {
+			log.Printf("Error loading feed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving feed"})
+			return
+		}
+
+		body, err := render(feed)
+		if err != nil {
+			log.Printf("Error rendering feed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error rendering feed"})
+			return
+		}
+
+		serveWithValidators(c, contentType, body, feed.Updated)
+	}
+}
+
+// GetRSSFeed serves published posts and projects as RSS 2.0
+func GetRSSFeed() gin.HandlerFunc {
+	return feedHandler("application/rss+xml; charset=utf-8", helpers.Feed.RSS)
+}
+
+// GetAtomFeed serves published posts and projects as Atom
+func GetAtomFeed() gin.HandlerFunc {
+	return feedHandler("application/atom+xml; charset=utf-8", helpers.Feed.Atom)
+}
+
+// GetJSONFeed serves published posts and projects as JSON Feed 1.1
+func GetJSONFeed() gin.HandlerFunc {
+	return feedHandler("application/feed+json; charset=utf-8", helpers.Feed.JSONFeed)
+}
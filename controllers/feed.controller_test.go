@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFeedURLIgnoresRequestHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		apiURL       string
+		portfolioURL string
+		want         string
+	}{
+		{"api url", "https://api.example.com/", "https://www.example.com", "https://api.example.com/portfolio/feed.xml"},
+		{"proxied by the frontend", "", "https://www.example.com", "https://www.example.com/portfolio/feed.xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("API_URL", tt.apiURL)
+			t.Setenv("PORTFOLIO_URL", tt.portfolioURL)
+
+			var got string
+			router := gin.New()
+			router.GET("/portfolio/feed.xml", func(c *gin.Context) { got = feedURL(c) })
+
+			req := httptest.NewRequest(http.MethodGet, "/portfolio/feed.xml?utm=1", nil)
+			req.Host = "evil.example.net"
+			req.Header.Set("X-Forwarded-Proto", "gopher")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("feedURL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePublicURLs(t *testing.T) {
+	tests := []struct {
+		name         string
+		portfolioURL string
+		apiURL       string
+		wantErr      bool
+	}{
+		{"valid", "https://www.example.com", "", false},
+		{"valid with api", "https://www.example.com/", "https://api.example.com", false},
+		{"missing", "", "", true},
+		{"relative", "/portfolio", "", true},
+		{"no scheme", "www.example.com", "", true},
+		{"other scheme", "ftp://www.example.com", "", true},
+		{"query", "https://www.example.com/?a=1", "", true},
+		{"relative api", "https://www.example.com", "/api", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PORTFOLIO_URL", tt.portfolioURL)
+			t.Setenv("API_URL", tt.apiURL)
+			if err := ValidatePublicURLs(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePublicURLs() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
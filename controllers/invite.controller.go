@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"portfolio/database"
+	"portfolio/helpers"
+	"portfolio/middleware"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var InviteCollection *mongo.Collection = database.PortfolioData(database.Client, "Invites")
+
+var (
+	errRegistrationClosed = errors.New("registration is closed")
+	errInviteRequired     = errors.New("an invite code is required")
+	errInviteInvalid      = errors.New("invite code is invalid, expired or used up")
+)
+
+// registrationErrorResponse maps registration mode errors to a response
+func registrationErrorResponse(c *gin.Context, err error) {
+	switch err {
+	case errRegistrationClosed:
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "Registration is closed"})
+	case errInviteRequired:
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "An invite code is required to register"})
+	case errInviteInvalid:
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "Invite code is invalid, expired or used up"})
+	default:
+		log.Printf("Error checking registration: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+	}
+}
+
+// admitRegistration checks the registration mode for a new user. In invite only mode it redeems the code for the
+// user and returns the invite, whose role the new user is given; release undoes the redemption if creating the
+// user then fails.
+func admitRegistration(ctx context.Context, inviteCode string, userID primitive.ObjectID, email string) (invite *models.Invite, release func(), err error) {
+	release = func() {}
+
+	settings, err := getSettings(ctx)
+	if err != nil {
+		return nil, release, err
+	}
+
+	switch settings.Registration_Mode {
+	case models.RegistrationClosed:
+		return nil, release, errRegistrationClosed
+	case models.RegistrationInviteOnly:
+		if strings.TrimSpace(inviteCode) == "" {
+			return nil, release, errInviteRequired
+		}
+		invite, err := redeemInvite(ctx, strings.TrimSpace(inviteCode), userID.Hex(), email)
+		if err != nil {
+			return nil, release, err
+		}
+		release = func() { releaseInvite(invite.Invite_ID, userID.Hex()) }
+		return invite, release, nil
+	default:
+		return nil, release, nil
+	}
+}
+
+// redeemInvite atomically records a use of the code, so concurrent sign ups cannot exceed its limit
+func redeemInvite(ctx context.Context, code string, userID string, email string) (*models.Invite, error) {
+	now := time.Now()
+	filter := bson.M{
+		"code":    code,
+		"revoked": false,
+		"$expr":   bson.M{"$lt": bson.A{"$uses", "$max_uses"}},
+		"$or":     bson.A{bson.M{"expires_at": nil}, bson.M{"expires_at": bson.M{"$gt": now}}},
+	}
+	update := bson.M{
+		"$inc":  bson.M{"uses": 1},
+		"$push": bson.M{"used_by": models.InviteUse{User_ID: userID, Email: email, Used_At: now}},
+	}
+
+	var invite models.Invite
+	err := InviteCollection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&invite)
+	if err == mongo.ErrNoDocuments {
+		return nil, errInviteInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+func releaseInvite(inviteID primitive.ObjectID, userID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := InviteCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": inviteID, "used_by.user_id": userID},
+		bson.M{"$inc": bson.M{"uses": -1}, "$pull": bson.M{"used_by": bson.M{"user_id": userID}}},
+	)
+	if err != nil {
+		log.Printf("Error releasing invite: %v", err)
+	}
+}
+
+func CreateInvite() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var inviteData struct {
+			Role          string     `json:"role"`
+			Max_Uses      int        `json:"max_uses"`
+			Expires_At    *time.Time `json:"expires_at"`
+			ExpiresInDays int        `json:"expires_in_days"`
+		}
+		if err := c.BindJSON(&inviteData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		if inviteData.Role == "" {
+			inviteData.Role = models.RoleUser
+		}
+		if inviteData.Max_Uses == 0 {
+			inviteData.Max_Uses = 1
+		}
+		if inviteData.Max_Uses < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Max uses must be at least 1"})
+			return
+		}
+
+		var role models.Role
+		if err := RoleCollection.FindOne(ctx, bson.M{"_id": inviteData.Role}).Decode(&role); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid role"})
+			return
+		}
+		// Inviting someone with a role grants its permissions, so the caller must hold them
+		if !bindRolePermissions(c, role.Permissions) {
+			return
+		}
+
+		expiresAt := inviteData.Expires_At
+		if expiresAt == nil && inviteData.ExpiresInDays > 0 {
+			expiry := time.Now().AddDate(0, 0, inviteData.ExpiresInDays)
+			expiresAt = &expiry
+		}
+		if expiresAt != nil && expiresAt.Before(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Expiry must be in the future"})
+			return
+		}
+
+		code, err := helpers.GenerateRandomToken(9)
+		if err != nil {
+			log.Printf("Error generating invite code: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating invite"})
+			return
+		}
+
+		invite := models.Invite{
+			Invite_ID:  primitive.NewObjectID(),
+			Code:       code,
+			Role:       inviteData.Role,
+			Max_Uses:   inviteData.Max_Uses,
+			Used_By:    []models.InviteUse{},
+			Expires_At: expiresAt,
+			Created_By: c.GetString("userId"),
+			Created_At: time.Now(),
+		}
+		if _, err := InviteCollection.InsertOne(ctx, invite); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating invite"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Invite created successfully", "invite": invite})
+	}
+}
+
+// visibleInviteCodeLength is how much of a masked invite code is shown, enough to tell invites apart
+const visibleInviteCodeLength = 4
+
+// maskInviteCode hides all but the start of an invite code
+func maskInviteCode(code string) string {
+	if len(code) <= visibleInviteCodeLength {
+		return strings.Repeat("*", len(code))
+	}
+	return code[:visibleInviteCodeLength] + strings.Repeat("*", len(code)-visibleInviteCodeLength)
+}
+
+// inviteCodeVisible reports whether a caller may see an invite's code: only those who could have created
+// the invite, otherwise a read only admin could sign up with a more privileged role or pass the code on
+func inviteCodeVisible(callerPermissions []string, invitePermissions []string) bool {
+	return middleware.HasPermission(callerPermissions, models.PermissionUsersWrite) && canGrant(callerPermissions, invitePermissions)
+}
+
+// maskInviteCodes masks the codes of invites the caller may not see
+func maskInviteCodes(c *gin.Context, invites []models.Invite) error {
+	callerPerms, err := callerPermissions(c)
+	if err != nil {
+		return err
+	}
+	for i := range invites {
+		invitePerms, err := middleware.RolePermissions(invites[i].Role)
+		if err != nil {
+			return err
+		}
+		if !inviteCodeVisible(callerPerms, invitePerms) {
+			invites[i].Code = maskInviteCode(invites[i].Code)
+		}
+	}
+	return nil
+}
+
+// GetAllInvites lists invites with how often each has been used and by whom
+func GetAllInvites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		invites := []models.Invite{}
+		cursor, err := InviteCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"created_at": -1}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving invites"})
+			return
+		}
+		if err = cursor.All(ctx, &invites); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error decoding invites"})
+			return
+		}
+		if err := maskInviteCodes(c, invites); err != nil {
+			log.Printf("Error retrieving role permissions: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving invites"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "invites": invites})
+	}
+}
+
+func GetOneInvite() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid invite ID"})
+			return
+		}
+
+		var invite models.Invite
+		if err := InviteCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&invite); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Invite not found"})
+			return
+		}
+		invites := []models.Invite{invite}
+		if err := maskInviteCodes(c, invites); err != nil {
+			log.Printf("Error retrieving role permissions: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving invite"})
+			return
+		}
+		invite = invites[0]
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "invite": invite})
+	}
+}
+
+// RevokeInvite stops an invite from being used; it is kept so its usage stays on record
+func RevokeInvite() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid invite ID"})
+			return
+		}
+
+		result, err := InviteCollection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"revoked": true}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error revoking invite"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Invite not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Invite revoked successfully"})
+	}
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"testing"
+
+	"portfolio/models"
+)
+
+func TestMaskInviteCode(t *testing.T) {
+	tests := map[string]string{
+		"":             "",
+		"abc":          "***",
+		"abcd":         "****",
+		"abcdefghijkl": "abcd********",
+	}
+	for code, want := range tests {
+		if got := maskInviteCode(code); got != want {
+			t.Errorf("maskInviteCode(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestInviteCodeVisible(t *testing.T) {
+	readOnly := []string{models.PermissionUsersRead}
+	userManager := []string{models.PermissionUsersRead, models.PermissionUsersWrite, models.PermissionExpensesRead, models.PermissionExpensesWrite}
+	userRole := []string{models.PermissionExpensesRead, models.PermissionExpensesWrite}
+
+	tests := []struct {
+		name   string
+		caller []string
+		invite []string
+		want   bool
+	}{
+		{"read only admin", readOnly, userRole, false},
+		{"user manager inviting users", userManager, userRole, true},
+		{"user manager and an admin invite", userManager, models.ExpenseAdminPermissions, false},
+		{"full admin and an admin invite", append(append([]string{}, models.ExpenseAdminPermissions...), userRole...), models.ExpenseAdminPermissions, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inviteCodeVisible(tt.caller, tt.invite); got != tt.want {
+				t.Errorf("inviteCodeVisible = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	token "portfolio/tokens"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS publishes the public token verification keys so other services can validate our tokens
+func GetJWKS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jwks, err := token.JWKS()
+		if err != nil {
+			log.Printf("Error loading signing keys: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error loading signing keys"})
+			return
+		}
+
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, jwks)
+	}
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"log"
 	"net/http"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var LayoutCollection *mongo.Collection = database.PortfolioData(database.Client, "Layouts")
@@ -21,7 +23,10 @@ func ManageLayout() gin.HandlerFunc {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		layoutType := c.Query("type")
+		layoutType, ok := findLayoutType(c, c.Query("type"))
+		if !ok {
+			return
+		}
 
 		switch c.Request.Method {
 		case http.MethodGet:
@@ -36,174 +41,126 @@ func ManageLayout() gin.HandlerFunc {
 	}
 }
 
-func createLayout(ctx context.Context, c *gin.Context, layoutType string) {
-	var layoutData interface{}
-	var err error
-
-	switch layoutType {
-	case "about_me":
-		var aboutMe models.AboutMe
-		err = c.BindJSON(&aboutMe)
-		layoutData = aboutMe
-	case "service_info":
-		var serviceInfo models.ServiceInfo
-		err = c.BindJSON(&serviceInfo)
-		layoutData = serviceInfo
-	case "project_info":
-		var projectInfo models.ProjectInfo
-		err = c.BindJSON(&projectInfo)
-		layoutData = projectInfo
-	case "blog_info":
-		var blogInfo models.Blog
-		err = c.BindJSON(&blogInfo)
-		layoutData = blogInfo
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid layout type"})
+func createLayout(ctx context.Context, c *gin.Context, layoutType layoutType) {
+	layoutData, ok := bindLayout(c, layoutType)
+	if !ok {
 		return
 	}
 
+	data, err := layoutDocument(layoutData)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating layout"})
 		return
 	}
 
-	_, err = LayoutCollection.InsertOne(ctx, bson.M{"type": layoutType, "data": layoutData})
+	_, err = LayoutCollection.InsertOne(ctx, bson.M{"type": layoutType.name, "data": data, "revision_count": 0})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating layout"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"success": true, "message": layoutType + " created successfully"})
+	revision, err := saveLayoutRevision(ctx, c, layoutType.name, data, false, 0)
+	if err != nil {
+		log.Printf("Error saving layout revision: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating layout"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "message": layoutType.name + " created successfully", "version": revision.Version})
 }
 
-func updateLayout(ctx context.Context, c *gin.Context, layoutType string) {
-	var layoutData interface{}
-	var err error
-
-	switch layoutType {
-	case "about_me":
-		var aboutMe models.AboutMe
-		err = c.BindJSON(&aboutMe)
-		layoutData = aboutMe
-	case "service_info":
-		var serviceInfo models.ServiceInfo
-		err = c.BindJSON(&serviceInfo)
-		layoutData = serviceInfo
-	case "project_info":
-		var projectInfo models.ProjectInfo
-		err = c.BindJSON(&projectInfo)
-		layoutData = projectInfo
-	case "blog_info":
-		var blogInfo models.Blog
-		err = c.BindJSON(&blogInfo)
-		layoutData = blogInfo
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid layout type"})
+func updateLayout(ctx context.Context, c *gin.Context, layoutType layoutType) {
+	layoutData, ok := bindLayout(c, layoutType)
+	if !ok {
 		return
 	}
 
+	data, err := layoutDocument(layoutData)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error updating layout"})
 		return
 	}
 
-	update := bson.M{"$set": bson.M{"data": layoutData}}
+	// Translations left out of the request are kept, while an empty set clears them
+	if *layoutTranslations(layoutData) == nil {
+		if err := keepLayoutTranslations(ctx, layoutType.name, data); err != nil {
+			log.Printf("Error loading layout translations: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error updating layout"})
+			return
+		}
+	}
 
-	result, err := LayoutCollection.UpdateOne(ctx, bson.M{"type": layoutType}, update)
+	// Drafts are kept as revisions for previewing without changing the live layout
+	draft := c.Query("draft") == "true"
+	revision, err := saveLayoutRevision(ctx, c, layoutType.name, data, draft, 0)
+	if err == errLayoutNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": layoutType.name + " not found"})
+		return
+	}
 	if err != nil {
+		log.Printf("Error saving layout revision: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error updating layout"})
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": layoutType + " not found"})
+	if draft {
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": layoutType.name + " draft saved successfully", "version": revision.Version})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": layoutType.name + " updated successfully", "version": revision.Version})
+}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": layoutType + " updated successfully"})
+// keepLayoutTranslations copies the translations of the live layout into data
+func keepLayoutTranslations(ctx context.Context, layoutType string, data map[string]interface{}) error {
+	var stored struct {
+		Data struct {
+			Translations models.Translations `bson:"translations"`
+		} `bson:"data"`
+	}
+	err := LayoutCollection.FindOne(ctx, bson.M{"type": layoutType},
+		options.FindOne().SetProjection(bson.M{"data.translations": 1})).Decode(&stored)
+	if err == mongo.ErrNoDocuments {
+		// Saving reports the missing layout
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if stored.Data.Translations != nil {
+		data["translations"] = stored.Data.Translations
+	}
+	return nil
 }
 
-func getLayout(ctx context.Context, c *gin.Context, layoutType string) {
-	type LayoutData struct {
-		ID   primitive.ObjectID `json:"_id" bson:"_id"`
-		Type string             `json:"type" bson:"type"`
-		Data interface{}        `json:"data" bson:"data"`
-	}
-
-	var layoutData LayoutData
-	layoutData.Type = layoutType
-
-	switch layoutType {
-	case "about_me":
-		var aboutMe models.AboutMe
-		err := LayoutCollection.FindOne(ctx, bson.M{"type": layoutType}).Decode(&struct {
-			ID   primitive.ObjectID `json:"_id" bson:"_id"`
-			Type string             `json:"type" bson:"type"`
-			Data *models.AboutMe    `json:"data" bson:"data"`
-		}{
-			ID:   layoutData.ID,
-			Type: layoutData.Type,
-			Data: &aboutMe,
-		})
-		layoutData.Data = aboutMe
-		if err != nil {
-			handleError(c, err, layoutType)
-			return
-		}
-	case "service_info":
-		var serviceInfo models.ServiceInfo
-		err := LayoutCollection.FindOne(ctx, bson.M{"type": layoutType}).Decode(&struct {
-			ID   primitive.ObjectID  `json:"_id" bson:"_id"`
-			Type string              `json:"type" bson:"type"`
-			Data *models.ServiceInfo `json:"data" bson:"data"`
-		}{
-			ID:   layoutData.ID,
-			Type: layoutData.Type,
-			Data: &serviceInfo,
-		})
-		layoutData.Data = serviceInfo
-		if err != nil {
-			handleError(c, err, layoutType)
-			return
-		}
-	case "project_info":
-		var projectInfo models.ProjectInfo
-		err := LayoutCollection.FindOne(ctx, bson.M{"type": layoutType}).Decode(&struct {
-			ID   primitive.ObjectID  `json:"_id" bson:"_id"`
-			Type string              `json:"type" bson:"type"`
-			Data *models.ProjectInfo `json:"data" bson:"data"`
-		}{
-			ID:   layoutData.ID,
-			Type: layoutData.Type,
-			Data: &projectInfo,
-		})
-		layoutData.Data = projectInfo
-		if err != nil {
-			handleError(c, err, layoutType)
-			return
-		}
-	case "blog_info":
-		var blogInfo models.Blog
-		err := LayoutCollection.FindOne(ctx, bson.M{"type": layoutType}).Decode(&struct {
-			ID   primitive.ObjectID `json:"_id" bson:"_id"`
-			Type string             `json:"type" bson:"type"`
-			Data *models.Blog       `json:"data" bson:"data"`
-		}{
-			ID:   layoutData.ID,
-			Type: layoutData.Type,
-			Data: &blogInfo,
-		})
-		layoutData.Data = blogInfo
-		if err != nil {
-			handleError(c, err, layoutType)
+func getLayout(ctx context.Context, c *gin.Context, layoutType layoutType) {
+	var stored struct {
+		ID      primitive.ObjectID `bson:"_id"`
+		Version int                `bson:"version"`
+		Data    bson.RawValue      `bson:"data"`
+	}
+	err := LayoutCollection.FindOne(ctx, bson.M{"type": layoutType.name}).Decode(&stored)
+	if err != nil {
+		handleError(c, err, layoutType.name)
+		return
+	}
+
+	// Fields missing from older documents keep the type's defaults
+	data := layoutType.defaults()
+	if stored.Data.Type == bson.TypeEmbeddedDocument {
+		if err := stored.Data.Unmarshal(data); err != nil {
+			handleError(c, err, layoutType.name)
 			return
 		}
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid layout type"})
-		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "layout": layoutData})
+	localize(requestLocale(c), data, layoutTranslations(data))
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "layout": gin.H{
+		"_id":     stored.ID,
+		"type":    layoutType.name,
+		"version": stored.Version,
+		"data":    data,
+	}})
 }
 
 func handleError(c *gin.Context, err error, layoutType string) {
@@ -0,0 +1,342 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"portfolio/database"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var LayoutRevisionCollection *mongo.Collection = database.PortfolioData(database.Client, "LayoutRevisions")
+
+var errLayoutNotFound = errors.New("layout not found")
+
+// liveLayoutFields are kept up to date on the live layout outside of editing, such as the about me view count.
+// Revisions never store them, so saving or restoring a revision cannot roll them back.
+var liveLayoutFields = []string{"view_count"}
+
+// EnsureLayoutRevisionIndexes makes revision numbers unique per layout, which also lets the migration re-run safely
+func EnsureLayoutRevisionIndexes(ctx context.Context) error {
+	_, err := LayoutRevisionCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "type", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// withoutLiveFields returns a copy of layout data without its live fields
+func withoutLiveFields(data map[string]interface{}) map[string]interface{} {
+	content := make(map[string]interface{}, len(data))
+	for field, value := range data {
+		content[field] = value
+	}
+	for _, field := range liveLayoutFields {
+		delete(content, field)
+	}
+	return content
+}
+
+// layoutDocument converts bound layout data into the plain document a revision stores
+func layoutDocument(data interface{}) (map[string]interface{}, error) {
+	raw, err := bson.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var document map[string]interface{}
+	if err := bson.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// nextLayoutVersion reserves the next revision number of a layout
+func nextLayoutVersion(ctx context.Context, layoutType string) (int, error) {
+	var layout struct {
+		Revision_Count int `bson:"revision_count"`
+	}
+	err := LayoutCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"type": layoutType},
+		bson.M{"$inc": bson.M{"revision_count": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"revision_count": 1}),
+	).Decode(&layout)
+	if err == mongo.ErrNoDocuments {
+		return 0, errLayoutNotFound
+	}
+	return layout.Revision_Count, err
+}
+
+// saveLayoutRevision stores data as a new revision of a layout and, unless it is a draft, makes it live.
+// A revision only goes live while it is newer than the live one, so when two saves race the later version wins
+// whichever finishes first.
+func saveLayoutRevision(ctx context.Context, c *gin.Context, layoutType string, data map[string]interface{}, draft bool, restoredFrom int) (models.LayoutRevision, error) {
+	data = withoutLiveFields(data)
+	revision := models.LayoutRevision{
+		Revision_ID:   primitive.NewObjectID(),
+		Type:          layoutType,
+		Data:          data,
+		Draft:         draft,
+		Restored_From: restoredFrom,
+		Author:        c.GetString("email"),
+		Created_At:    time.Now(),
+	}
+
+	version, err := nextLayoutVersion(ctx, layoutType)
+	if err != nil {
+		return revision, err
+	}
+	revision.Version = version
+
+	if _, err := LayoutRevisionCollection.InsertOne(ctx, revision); err != nil {
+		return revision, err
+	}
+	if draft {
+		return revision, nil
+	}
+
+	// The live fields are carried over from the stored layout instead of being taken from the revision
+	live := bson.M{}
+	for _, field := range liveLayoutFields {
+		live[field] = "$data." + field
+	}
+	_, err = LayoutCollection.UpdateOne(
+		ctx,
+		bson.M{"type": layoutType, "version": bson.M{"$not": bson.M{"$gte": version}}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"data":    bson.M{"$mergeObjects": bson.A{bson.M{"$literal": data}, live}},
+			"version": version,
+		}}}},
+	)
+	return revision, err
+}
+
+// MigrateLayoutRevisions records the content of layouts saved before revisions existed as their first revision.
+// The revision is upserted, so a run cut short before marking the layout can simply be repeated.
+func MigrateLayoutRevisions(ctx context.Context) error {
+	cursor, err := LayoutCollection.Find(ctx, bson.M{"revision_count": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+
+	var layouts []struct {
+		ID   primitive.ObjectID     `bson:"_id"`
+		Type string                 `bson:"type"`
+		Data map[string]interface{} `bson:"data"`
+	}
+	if err := cursor.All(ctx, &layouts); err != nil {
+		return err
+	}
+
+	for _, layout := range layouts {
+		_, err := LayoutRevisionCollection.UpdateOne(
+			ctx,
+			bson.M{"type": layout.Type, "version": 1},
+			bson.M{"$setOnInsert": bson.M{
+				"_id":        primitive.NewObjectID(),
+				"data":       withoutLiveFields(layout.Data),
+				"draft":      false,
+				"author":     "",
+				"created_at": time.Now(),
+			}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+		_, err = LayoutCollection.UpdateOne(ctx, bson.M{"_id": layout.ID}, bson.M{"$set": bson.M{"version": 1, "revision_count": 1}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findLayoutRevision loads a revision of the layout named by the type query parameter.
+// A version of 0 means the newest draft.
+func findLayoutRevision(ctx context.Context, c *gin.Context, version int) (models.LayoutRevision, bool) {
+	var revision models.LayoutRevision
+
+	filter := bson.M{"type": c.Query("type"), "version": version}
+	opts := options.FindOne()
+	if version == 0 {
+		filter = bson.M{"type": c.Query("type"), "draft": true}
+		opts.SetSort(bson.M{"version": -1})
+	}
+
+	err := LayoutRevisionCollection.FindOne(ctx, filter, opts).Decode(&revision)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Revision not found"})
+		return revision, false
+	}
+	if err != nil {
+		log.Printf("Error retrieving layout revision: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving revision"})
+		return revision, false
+	}
+	return revision, true
+}
+
+// versionParam parses a revision number from the query, responding when it is missing or invalid
+func versionParam(c *gin.Context, name string) (int, bool) {
+	version, err := strconv.Atoi(c.Query(name))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid " + name + " revision"})
+		return 0, false
+	}
+	return version, true
+}
+
+// GetLayoutRevisions lists the revisions of a layout, newest first, a page at a time
+func GetLayoutRevisions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{"type": c.Query("type")}
+		page, limit := paginationParams(c)
+		opts := options.Find().
+			SetSort(bson.M{"version": -1}).
+			SetSkip(int64((page - 1) * limit)).
+			SetLimit(int64(limit))
+
+		total, err := LayoutRevisionCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			log.Printf("Error counting layout revisions: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving revisions"})
+			return
+		}
+
+		revisions := []models.LayoutRevision{}
+		cursor, err := LayoutRevisionCollection.Find(ctx, filter, opts)
+		if err != nil {
+			log.Printf("Error finding layout revisions: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving revisions"})
+			return
+		}
+		if err := cursor.All(ctx, &revisions); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error decoding revisions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "revisions": revisions, "total": total, "page": page, "limit": limit})
+	}
+}
+
+// DiffLayoutRevisions lists the fields that differ between the from and to revisions of a layout
+func DiffLayoutRevisions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, ok := versionParam(c, "from")
+		if !ok {
+			return
+		}
+		to, ok := versionParam(c, "to")
+		if !ok {
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		fromRevision, ok := findLayoutRevision(ctx, c, from)
+		if !ok {
+			return
+		}
+		toRevision, ok := findLayoutRevision(ctx, c, to)
+		if !ok {
+			return
+		}
+
+		changes := layoutChanges(fromRevision.Data, toRevision.Data)
+		c.JSON(http.StatusOK, gin.H{"success": true, "from": from, "to": to, "changes": changes})
+	}
+}
+
+// layoutChanges lists the content fields that differ between two revisions, sorted by name. Live fields are
+// skipped, since revisions saved before they were left out may still hold them.
+func layoutChanges(from, to map[string]interface{}) []gin.H {
+	from, to = withoutLiveFields(from), withoutLiveFields(to)
+
+	fields := make(map[string]bool)
+	for field := range from {
+		fields[field] = true
+	}
+	for field := range to {
+		fields[field] = true
+	}
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	changes := []gin.H{}
+	for _, field := range names {
+		if !reflect.DeepEqual(from[field], to[field]) {
+			changes = append(changes, gin.H{"field": field, "from": from[field], "to": to[field]})
+		}
+	}
+	return changes
+}
+
+// RestoreLayoutRevision makes an old revision, or a draft, live again as a new revision
+func RestoreLayoutRevision() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, ok := versionParam(c, "version")
+		if !ok {
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		revision, ok := findLayoutRevision(ctx, c, version)
+		if !ok {
+			return
+		}
+
+		restored, err := saveLayoutRevision(ctx, c, revision.Type, revision.Data, false, revision.Version)
+		if err != nil {
+			log.Printf("Error restoring layout revision: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error restoring revision"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": revision.Type + " restored successfully", "revision": restored})
+	}
+}
+
+// PreviewLayout serves the newest draft of a layout, or the revision given by version, to admins
+func PreviewLayout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version := 0
+		if c.Query("version") != "" {
+			var ok bool
+			if version, ok = versionParam(c, "version"); !ok {
+				return
+			}
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		revision, ok := findLayoutRevision(ctx, c, version)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "layout": gin.H{"type": revision.Type, "data": revision.Data}, "revision": revision})
+	}
+}
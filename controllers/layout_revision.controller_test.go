@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"testing"
+)
+
+func TestWithoutLiveFields(t *testing.T) {
+	data := map[string]interface{}{"title": "About", "view_count": int32(42)}
+
+	content := withoutLiveFields(data)
+	if _, ok := content["view_count"]; ok {
+		t.Error("withoutLiveFields kept view_count")
+	}
+	if content["title"] != "About" {
+		t.Errorf("title = %v, want About", content["title"])
+	}
+	if data["view_count"] != int32(42) {
+		t.Error("withoutLiveFields modified its input")
+	}
+}
+
+func TestLayoutChanges(t *testing.T) {
+	from := map[string]interface{}{"title": "Old", "bio": "Same", "view_count": int32(3)}
+	to := map[string]interface{}{"title": "New", "bio": "Same", "image": "a.png", "view_count": int32(90)}
+
+	changes := layoutChanges(from, to)
+	var fields []string
+	for _, change := range changes {
+		fields = append(fields, change["field"].(string))
+	}
+	if len(fields) != 2 || fields[0] != "image" || fields[1] != "title" {
+		t.Fatalf("changed fields = %v, want [image title]", fields)
+	}
+	if changes[1]["from"] != "Old" || changes[1]["to"] != "New" {
+		t.Errorf("title change = %v", changes[1])
+	}
+}
+
+func TestLayoutChangesIdentical(t *testing.T) {
+	data := map[string]interface{}{"title": "Same"}
+	if changes := layoutChanges(data, data); len(changes) != 0 {
+		t.Errorf("layoutChanges = %v, want none", changes)
+	}
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"portfolio/helpers"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// layoutType describes a section of the portfolio layout: the struct its data binds to, the values a new
+// section starts with and how its data is checked
+type layoutType struct {
+	name        string
+	description string
+	// defaults returns a pointer to the section's struct with its default values filled in
+	defaults func() interface{}
+	// required lists the JSON names of fields that must not be empty
+	required []string
+	// translatable lists the JSON names of fields that may be translated
+	translatable []string
+	// validate makes any further checks and may be nil
+	validate func(data interface{}) error
+}
+
+var layoutTypes = make(map[string]layoutType)
+
+// registerLayoutType adds a section that can be managed through /manage-layout?type=<name>
+func registerLayoutType(t layoutType) {
+	if _, exists := layoutTypes[t.name]; exists {
+		panic("layout type registered twice: " + t.name)
+	}
+	layoutTypes[t.name] = t
+}
+
+func init() {
+	registerLayoutType(layoutType{
+		name:         "about_me",
+		description:  "Introduction with contact links",
+		defaults:     func() interface{} { return &models.AboutMe{} },
+		required:     []string{"name"},
+		translatable: []string{"role", "description"},
+	})
+	registerLayoutType(layoutType{
+		name:         "service_info",
+		description:  "Heading of the services section",
+		defaults:     func() interface{} { return &models.ServiceInfo{Title: "Services"} },
+		required:     []string{"title"},
+		translatable: []string{"title", "description"},
+	})
+	registerLayoutType(layoutType{
+		name:         "project_info",
+		description:  "Heading of the projects section",
+		defaults:     func() interface{} { return &models.ProjectInfo{Title: "Projects"} },
+		required:     []string{"title"},
+		translatable: []string{"title", "description"},
+	})
+	registerLayoutType(layoutType{
+		name:         "blog_info",
+		description:  "Heading and link of the blog section",
+		defaults:     func() interface{} { return &models.Blog{Title: "Blog"} },
+		required:     []string{"title"},
+		translatable: []string{"title", "sub_title", "description"},
+		validate: func(data interface{}) error {
+			if link := data.(*models.Blog).Link; link != "" && !isWebURL(link) {
+				return errors.New("link must be an http or https URL")
+			}
+			return nil
+		},
+	})
+}
+
+func isWebURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// check validates data bound for this layout type
+func (t layoutType) check(data interface{}) error {
+	for _, name := range t.required {
+		field, ok := helpers.JSONFieldValue(data, name)
+		if ok && field.IsZero() {
+			return errors.New(name + " is required")
+		}
+	}
+	if err := checkTranslations(*layoutTranslations(data), t.translatable); err != nil {
+		return err
+	}
+	if t.validate != nil {
+		return t.validate(data)
+	}
+	return nil
+}
+
+// layoutTranslations points at the translations of layout data, or at an empty set if its type has none
+func layoutTranslations(data interface{}) *models.Translations {
+	if field, ok := helpers.JSONFieldValue(data, "translations"); ok {
+		if translations, ok := field.Addr().Interface().(*models.Translations); ok {
+			return translations
+		}
+	}
+	return &models.Translations{}
+}
+
+// schema is the JSON Schema of the layout type's data
+func (t layoutType) schema() map[string]interface{} {
+	schema := helpers.JSONSchema(t.defaults())
+	schema["title"] = t.name
+	schema["description"] = t.description
+	if len(t.required) > 0 {
+		schema["required"] = t.required
+	}
+	return schema
+}
+
+// findLayoutType looks up the layout type named by the request, responding when there is none
+func findLayoutType(c *gin.Context, name string) (layoutType, bool) {
+	t, ok := layoutTypes[name]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid layout type"})
+	}
+	return t, ok
+}
+
+// bindLayout binds the request body over the layout type's defaults and validates it
+func bindLayout(c *gin.Context, t layoutType) (interface{}, bool) {
+	data := t.defaults()
+	if err := c.BindJSON(data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return nil, false
+	}
+	if err := t.check(data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return nil, false
+	}
+	return data, true
+}
+
+// GetLayoutTypes lists the layout types with the JSON Schema of each, for building admin forms
+func GetLayoutTypes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		names := make([]string, 0, len(layoutTypes))
+		for name := range layoutTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		types := make([]gin.H, 0, len(names))
+		for _, name := range names {
+			t := layoutTypes[name]
+			types = append(types, gin.H{"type": t.name, "description": t.description, "schema": t.schema()})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "types": types})
+	}
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"portfolio/helpers"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Translatable fields of each kind of content, by JSON name
+var (
+	projectTranslatable     = []string{"title", "description"}
+	serviceTranslatable     = []string{"title", "content"}
+	certificateTranslatable = []string{"title", "content"}
+	postTranslatable        = []string{"title", "excerpt", "body"}
+	experienceTranslatable  = []string{"company", "role", "location", "description"}
+	educationTranslatable   = []string{"institution", "degree", "field", "location", "description"}
+	skillTranslatable       = []string{"name", "category"}
+)
+
+// checkTranslations rejects translations into unsupported locales, or of fields that are not translatable
+func checkTranslations(translations models.Translations, fields []string) error {
+	allowed := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		allowed[field] = true
+	}
+
+	for locale, values := range translations {
+		if locale == helpers.DefaultLocale() {
+			return errors.New(locale + " is the default locale, its text belongs in the fields themselves")
+		}
+		if !helpers.IsSupportedLocale(locale) {
+			return errors.New("Unsupported locale: " + locale)
+		}
+		for field := range values {
+			if !allowed[field] {
+				return errors.New("Field cannot be translated: " + field)
+			}
+		}
+	}
+	return nil
+}
+
+// validTranslations checks the translations of a create or update request, responding when they are invalid
+func validTranslations(c *gin.Context, translations models.Translations, fields []string) bool {
+	if err := checkTranslations(translations, fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// requestLocale picks the locale to respond in from ?lang=, then Accept-Language, then the default.
+// Editors see content as stored, with its translations, unless they ask for a locale with ?lang=.
+func requestLocale(c *gin.Context) string {
+	if canViewUnpublished(c) && c.Query("lang") == "" {
+		return ""
+	}
+
+	locale := helpers.NegotiateLocale(c.Query("lang"), c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", locale)
+	c.Header("Vary", "Accept-Language")
+	return locale
+}
+
+// localize shows value, a pointer to content, in locale. Fields without a translation keep the default
+// locale text, and the translations themselves are left out. An empty locale leaves value as stored.
+func localize(locale string, value interface{}, translations *models.Translations) {
+	if locale == "" {
+		return
+	}
+	if locale != helpers.DefaultLocale() {
+		helpers.ApplyTranslation(value, (*translations)[locale])
+	}
+	*translations = nil
+}
+
+// translationSource is a collection of translatable content checked for missing translations.
+// Reports name each document by its title field.
+type translationSource struct {
+	resource   string
+	collection *mongo.Collection
+	title      string
+	fields     []string
+}
+
+// missingTranslations lists, per locale, the fields of a document that have text but no translation
+func missingTranslations(document bson.M, translations bson.M, fields []string, locales []string) map[string][]string {
+	missing := make(map[string][]string)
+	for _, locale := range locales {
+		translated, _ := translations[locale].(bson.M)
+		for _, field := range fields {
+			if text, _ := document[field].(string); text == "" {
+				continue
+			}
+			if text, _ := translated[field].(string); text == "" {
+				missing[locale] = append(missing[locale], field)
+			}
+		}
+	}
+	return missing
+}
+
+// GetMissingTranslations reports content with fields not yet translated into every supported locale
+func GetMissingTranslations() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		locales := helpers.SupportedLocales()[1:]
+		report := []gin.H{}
+
+		sources := []translationSource{
+			{"project", ProjectCollection, "title", projectTranslatable},
+			{"service", ServiceCollection, "title", serviceTranslatable},
+			{"certificate", CertificateCollection, "title", certificateTranslatable},
+			{"post", PostCollection, "title", postTranslatable},
+			{"experience", ExperienceCollection, "role", experienceTranslatable},
+			{"education", EducationCollection, "institution", educationTranslatable},
+			{"skill", SkillCollection, "name", skillTranslatable},
+		}
+		for _, source := range sources {
+			projection := bson.M{source.title: 1, "translations": 1}
+			for _, field := range source.fields {
+				projection[field] = 1
+			}
+
+			cursor, err := source.collection.Find(ctx, bson.M{}, options.Find().SetProjection(projection))
+			if err != nil {
+				log.Printf("Error finding %s translations: %v", source.resource, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error checking translations"})
+				return
+			}
+			var documents []bson.M
+			if err := cursor.All(ctx, &documents); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error checking translations"})
+				return
+			}
+
+			for _, document := range documents {
+				translations, _ := document["translations"].(bson.M)
+				missing := missingTranslations(document, translations, source.fields, locales)
+				if len(missing) > 0 {
+					report = append(report, gin.H{"resource": source.resource, "id": document["_id"], "title": document[source.title], "missing": missing})
+				}
+			}
+		}
+
+		cursor, err := LayoutCollection.Find(ctx, bson.M{})
+		if err != nil {
+			log.Printf("Error finding layout translations: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error checking translations"})
+			return
+		}
+		var layouts []struct {
+			Type string `bson:"type"`
+			Data bson.M `bson:"data"`
+		}
+		if err := cursor.All(ctx, &layouts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error checking translations"})
+			return
+		}
+		for _, layout := range layouts {
+			layoutType, ok := layoutTypes[layout.Type]
+			if !ok || len(layoutType.translatable) == 0 {
+				continue
+			}
+			translations, _ := layout.Data["translations"].(bson.M)
+			missing := missingTranslations(layout.Data, translations, layoutType.translatable, locales)
+			if len(missing) > 0 {
+				report = append(report, gin.H{"resource": "layout", "id": layout.Type, "title": layout.Type, "missing": missing})
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "locales": locales, "missing": report})
+	}
+}
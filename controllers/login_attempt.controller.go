@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"portfolio/database"
+	"portfolio/helpers"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var LoginAttemptCollection = database.PortfolioData(database.Client, "LoginAttempts")
+
+var loginThrottle = helpers.NewLoginThrottle(loginAttemptStore())
+
+// loginAttemptStore picks where failed attempts are tracked; set LOGIN_ATTEMPT_STORE=memory for a single instance
+func loginAttemptStore() helpers.LoginAttemptStore {
+	if os.Getenv("LOGIN_ATTEMPT_STORE") == "memory" {
+		return helpers.NewMemoryLoginAttemptStore()
+	}
+	return helpers.NewMongoLoginAttemptStore(LoginAttemptCollection)
+}
+
+// EnsureLoginAttemptIndexes removes tracked login attempts once their window and any lockout have passed
+func EnsureLoginAttemptIndexes(ctx context.Context) error {
+	_, err := LoginAttemptCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{helpers.LoginAttemptExpiryField: 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+const invalidCredentialsMessage = "Invalid email or password"
+
+var (
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
+)
+
+// checkPasswordForUnknownUser spends the same time as a real password check so response
+// timing does not reveal whether an email is registered
+func checkPasswordForUnknownUser(password string) {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = helpers.HashPassword("dummy-password-for-timing")
+	})
+	helpers.CheckPassword(dummyPasswordHash, password)
+}
+
+// allowLoginAttempt responds with 429 and returns false while the account or client IP is locked out
+func allowLoginAttempt(ctx context.Context, c *gin.Context, email string) bool {
+	wait, err := loginThrottle.Check(ctx, email, c.ClientIP())
+	if err != nil {
+		log.Printf("Error checking login attempts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+		return false
+	}
+	if wait > 0 {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"success":     false,
+			"error":       "Too many failed login attempts. Please try again later",
+			"retry_after": int(wait.Seconds()) + 1,
+		})
+		return false
+	}
+	return true
+}
+
+// rejectLogin records a failed attempt and responds with the same message whatever went wrong
+func rejectLogin(ctx context.Context, c *gin.Context, email string) {
+	if err := loginThrottle.RecordFailure(ctx, email, c.ClientIP()); err != nil {
+		log.Printf("Error recording failed login: %v", err)
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": invalidCredentialsMessage})
+}
+
+// recordLoginSuccess clears the account's failed attempts
+func recordLoginSuccess(ctx context.Context, email string) {
+	if err := loginThrottle.RecordSuccess(ctx, email); err != nil {
+		log.Printf("Error resetting login attempts: %v", err)
+	}
+}
+
+func UnlockAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var unlockData struct {
+			Email string `json:"email"`
+			IP    string `json:"ip"`
+		}
+		if err := c.BindJSON(&unlockData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		if unlockData.Email == "" && unlockData.IP == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Email or IP is required"})
+			return
+		}
+
+		if unlockData.Email != "" {
+			if err := loginThrottle.Store.Reset(ctx, helpers.AccountAttemptKey(unlockData.Email)); err != nil {
+				log.Printf("Error unlocking account: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error unlocking account"})
+				return
+			}
+		}
+		if unlockData.IP != "" {
+			if err := loginThrottle.Store.Reset(ctx, helpers.IPAttemptKey(unlockData.IP)); err != nil {
+				log.Printf("Error unlocking IP: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error unlocking IP"})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Account unlocked successfully"})
+	}
+}
@@ -0,0 +1,375 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"portfolio/database"
+	"portfolio/helpers"
+	"portfolio/models"
+	"portfolio/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var MediaCollection *mongo.Collection = database.PortfolioData(database.Client, "Media")
+
+// MediaStorage is where uploaded images are kept, set up by ConfigureMediaStorage at startup
+var MediaStorage storage.Storage
+
+// ConfigureMediaStorage sets up the backend chosen by MEDIA_STORAGE. It runs before the routes are registered,
+// since local storage adds a route serving the files.
+func ConfigureMediaStorage() error {
+	store, err := storage.FromEnv()
+	if err != nil {
+		return err
+	}
+	MediaStorage = store
+	return nil
+}
+
+// EnsureMediaIndexes makes content hashes unique, so the same file uploaded twice at once is stored once
+func EnsureMediaIndexes(ctx context.Context) error {
+	_, err := MediaCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"hash": 1},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// mediaHashLength is how much of the content hash names stored files
+const mediaHashLength = 32
+
+var mediaContentTypes = map[string]string{
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"webp": "image/webp",
+}
+
+var mediaExtensions = map[string]string{
+	"jpeg": ".jpg",
+	"png":  ".png",
+	"webp": ".webp",
+}
+
+// maxUploadSize is the largest upload accepted, MEDIA_MAX_UPLOAD_MB megabytes or 10 by default
+func maxUploadSize() int64 {
+	if size, err := strconv.ParseInt(os.Getenv("MEDIA_MAX_UPLOAD_MB"), 10, 64); err == nil && size > 0 {
+		return size << 20
+	}
+	return 10 << 20
+}
+
+// mediaReference lists the fields of a collection that may hold the URL of an uploaded image, and where its
+// translations are kept, since any translated text may link an image too
+type mediaReference struct {
+	name         string
+	collection   *mongo.Collection
+	fields       []string
+	translations string
+}
+
+func mediaReferences() []mediaReference {
+	return []mediaReference{
+		{"projects", ProjectCollection, []string{"image"}, "translations"},
+		{"services", ServiceCollection, []string{"image"}, "translations"},
+		{"certificates", CertificateCollection, []string{"image"}, "translations"},
+		{"posts", PostCollection, []string{"cover_image", "body"}, "translations"},
+		{"experience", ExperienceCollection, nil, "translations"},
+		{"education", EducationCollection, nil, "translations"},
+		{"skills", SkillCollection, nil, "translations"},
+		{"layouts", LayoutCollection, []string{"data.image"}, "data.translations"},
+		// An image only an old revision uses is still needed to restore it
+		{"layout revisions", LayoutRevisionCollection, []string{"data.image"}, "data.translations"},
+	}
+}
+
+// translationsMatch matches documents with any translated field, in any locale, matching the pattern
+func translationsMatch(path string, pattern string) bson.M {
+	return bson.M{"$expr": bson.M{"$anyElementTrue": bson.A{bson.M{"$map": bson.M{
+		"input": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$" + path, bson.M{}}}},
+		"as":    "locale",
+		"in": bson.M{"$anyElementTrue": bson.A{bson.M{"$map": bson.M{
+			"input": bson.M{"$objectToArray": "$$locale.v"},
+			"as":    "field",
+			"in":    bson.M{"$regexMatch": bson.M{"input": bson.M{"$toString": "$$field.v"}, "regex": pattern}},
+		}}}},
+	}}}}}
+}
+
+// mediaUsage lists where an image is still used. Every stored file carries the hash in its name, so
+// searching for the hash finds any of its variants.
+func mediaUsage(ctx context.Context, media models.Media) ([]string, error) {
+	hash := media.Hash[:mediaHashLength]
+	pattern := primitive.Regex{Pattern: hash}
+
+	var usedBy []string
+	for _, reference := range mediaReferences() {
+		conditions := bson.A{}
+		for _, field := range reference.fields {
+			conditions = append(conditions, bson.M{field: pattern})
+		}
+		if reference.translations != "" {
+			conditions = append(conditions, translationsMatch(reference.translations, hash))
+		}
+		count, err := reference.collection.CountDocuments(ctx, bson.M{"$or": conditions})
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			usedBy = append(usedBy, reference.name)
+		}
+	}
+	return usedBy, nil
+}
+
+// storeImageVariants writes the full size image and every smaller responsive width, each in the primary format
+// and as WebP. Files already written are removed again if one fails.
+func storeImageVariants(ctx context.Context, img image.Image, name string, format string) ([]models.MediaVariant, error) {
+	formats := []string{format}
+	if format != "webp" {
+		formats = append(formats, "webp")
+	}
+
+	images := []image.Image{img}
+	suffixes := []string{""}
+	for _, width := range helpers.ImageWidths {
+		if width < img.Bounds().Dx() {
+			images = append(images, helpers.ResizeImage(img, width))
+			suffixes = append(suffixes, "-"+strconv.Itoa(width))
+		}
+	}
+
+	var variants []models.MediaVariant
+	for i, resized := range images {
+		for _, variantFormat := range formats {
+			variant, err := storeImageVariant(ctx, resized, name+suffixes[i], variantFormat)
+			if err != nil {
+				deleteMediaFiles(ctx, variants)
+				return nil, err
+			}
+			variants = append(variants, variant)
+		}
+	}
+	return variants, nil
+}
+
+func storeImageVariant(ctx context.Context, img image.Image, name string, format string) (models.MediaVariant, error) {
+	data, err := helpers.EncodeImage(img, format)
+	if err != nil {
+		return models.MediaVariant{}, err
+	}
+
+	key := name + mediaExtensions[format]
+	if err := MediaStorage.Put(ctx, key, data, mediaContentTypes[format]); err != nil {
+		return models.MediaVariant{}, err
+	}
+
+	return models.MediaVariant{
+		Key:          key,
+		URL:          MediaStorage.URL(key),
+		Content_Type: mediaContentTypes[format],
+		Width:        img.Bounds().Dx(),
+		Height:       img.Bounds().Dy(),
+		Size:         len(data),
+	}, nil
+}
+
+// deleteMediaFiles removes stored files, logging any that could not be removed
+func deleteMediaFiles(ctx context.Context, variants []models.MediaVariant) {
+	for _, variant := range variants {
+		if err := MediaStorage.Delete(ctx, variant.Key); err != nil {
+			log.Printf("Error deleting media file %s: %v", variant.Key, err)
+		}
+	}
+}
+
+// UploadMedia stores an uploaded image with its EXIF stripped, alongside resized and WebP variants.
+// Uploading the same file again returns the existing media.
+func UploadMedia() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize())
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"success": false, "error": "Image is too large"})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "An image file is required"})
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Error reading image"})
+			return
+		}
+
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+
+		var existing models.Media
+		err = MediaCollection.FindOne(ctx, bson.M{"hash": hash}).Decode(&existing)
+		if err == nil {
+			c.JSON(http.StatusOK, gin.H{"success": true, "message": "Image was already uploaded", "media": existing})
+			return
+		}
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error retrieving media: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error uploading image"})
+			return
+		}
+
+		img, format, err := helpers.DecodeImage(data)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "The file must be a JPEG, PNG, GIF or WebP image"})
+			return
+		}
+		// JPEG photos stay JPEG; everything else may have transparency and is kept as PNG
+		if format != "jpeg" {
+			format = "png"
+		}
+
+		variants, err := storeImageVariants(ctx, img, hash[:mediaHashLength], format)
+		if err != nil {
+			log.Printf("Error storing image: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error uploading image"})
+			return
+		}
+
+		media := models.Media{
+			Media_ID:     primitive.NewObjectID(),
+			Hash:         hash,
+			Filename:     filepath.Base(header.Filename),
+			Alt:          strings.TrimSpace(c.PostForm("alt")),
+			Content_Type: mediaContentTypes[format],
+			Width:        img.Bounds().Dx(),
+			Height:       img.Bounds().Dy(),
+			Size:         variants[0].Size,
+			URL:          variants[0].URL,
+			Variants:     variants,
+			Uploaded_By:  c.GetString("email"),
+			Created_At:   time.Now(),
+		}
+		_, err = MediaCollection.InsertOne(ctx, media)
+		if mongo.IsDuplicateKeyError(err) {
+			// The same file was uploaded at the same time and saved first. Its files have the same names and
+			// content as ours, so they are kept and the other upload is returned.
+			if err := MediaCollection.FindOne(ctx, bson.M{"hash": hash}).Decode(&existing); err != nil {
+				log.Printf("Error retrieving media: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error uploading image"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": true, "message": "Image was already uploaded", "media": existing})
+			return
+		}
+		if err != nil {
+			log.Printf("Error saving media: %v", err)
+			deleteMediaFiles(ctx, variants)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error uploading image"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Image uploaded successfully", "media": media})
+	}
+}
+
+// GetAllMedia lists the media library, newest first, a page at a time
+func GetAllMedia() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		page, limit := paginationParams(c)
+		opts := options.Find().
+			SetSort(bson.M{"created_at": -1}).
+			SetSkip(int64((page - 1) * limit)).
+			SetLimit(int64(limit))
+
+		total, err := MediaCollection.CountDocuments(ctx, bson.M{})
+		if err != nil {
+			log.Printf("Error counting media: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving media"})
+			return
+		}
+
+		media := []models.Media{}
+		cursor, err := MediaCollection.Find(ctx, bson.M{}, opts)
+		if err != nil {
+			log.Printf("Error finding media: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving media"})
+			return
+		}
+		if err := cursor.All(ctx, &media); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error decoding media"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "media": media, "total": total, "page": page, "limit": limit})
+	}
+}
+
+// DeleteMedia removes an image and its files, refusing while any content still uses it
+func DeleteMedia() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid media ID"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var media models.Media
+		if err := MediaCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&media); err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Media not found"})
+				return
+			}
+			log.Printf("Error retrieving media: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error deleting media"})
+			return
+		}
+
+		usedBy, err := mediaUsage(ctx, media)
+		if err != nil {
+			log.Printf("Error checking media usage: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error deleting media"})
+			return
+		}
+		if len(usedBy) > 0 {
+			c.JSON(http.StatusConflict, gin.H{"success": false, "error": "Image is still in use", "used_by": usedBy})
+			return
+		}
+
+		if _, err := MediaCollection.DeleteOne(ctx, bson.M{"_id": objID}); err != nil {
+			log.Printf("Error deleting media: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error deleting media"})
+			return
+		}
+		deleteMediaFiles(ctx, media.Variants)
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Media deleted successfully"})
+	}
+}
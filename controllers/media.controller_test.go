@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMediaReferencesSearchTranslations(t *testing.T) {
+	want := map[string]string{
+		"Posts":           "translations",
+		"Projects":        "translations",
+		"Experience":      "translations",
+		"Skills":          "translations",
+		"Layouts":         "data.translations",
+		"LayoutRevisions": "data.translations",
+	}
+
+	found := map[string]string{}
+	for _, reference := range mediaReferences() {
+		found[reference.collection.Name()] = reference.translations
+	}
+	for collection, path := range want {
+		if found[collection] != path {
+			t.Errorf("%s references search translations at %q, want %q", collection, found[collection], path)
+		}
+	}
+}
+
+func TestTranslationsMatch(t *testing.T) {
+	filter := translationsMatch("data.translations", "abc123")
+
+	locales := filter["$expr"].(bson.M)["$anyElementTrue"].(bson.A)[0].(bson.M)["$map"].(bson.M)
+	input := locales["input"].(bson.M)["$objectToArray"].(bson.M)["$ifNull"].(bson.A)
+	if input[0] != "$data.translations" {
+		t.Errorf("translations path = %v, want $data.translations", input[0])
+	}
+
+	fields := locales["in"].(bson.M)["$anyElementTrue"].(bson.A)[0].(bson.M)["$map"].(bson.M)
+	match := fields["in"].(bson.M)["$regexMatch"].(bson.M)
+	if match["regex"] != "abc123" {
+		t.Errorf("regex = %v, want abc123", match["regex"])
+	}
+	if !reflect.DeepEqual(match["input"], bson.M{"$toString": "$$field.v"}) {
+		t.Errorf("input = %v, want every translated field", match["input"])
+	}
+}
@@ -0,0 +1,333 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"portfolio/database"
+	"portfolio/helpers"
+	"portfolio/middleware"
+	"portfolio/models"
+	"portfolio/oidc"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var OAuthStateCollection *mongo.Collection = database.PortfolioData(database.Client, "OAuthStates")
+
+var oidcProviders = oidc.NewRegistry(oidc.ConfigsFromEnv())
+
+// oauthStateTTL is how long the user has to finish signing in at the provider
+const oauthStateTTL = 10 * time.Minute
+
+var (
+	errOAuthEmailNotVerified    = errors.New("provider did not confirm the email address")
+	errOAuthLinkNeedsConfirm    = errors.New("account must link the provider while signed in")
+	errOAuthIdentityLinkedOther = errors.New("provider account is linked to another user")
+)
+
+// oauthPrivilegedPermissions are the permissions that stop a provider login from being linked to an account
+// by email alone. Whoever controls the email at the provider could otherwise take over an admin account.
+var oauthPrivilegedPermissions = append(append([]string{}, models.ExpenseAdminPermissions...), models.PortfolioAdminPermissions...)
+
+func GetOAuthProviders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		providers := oidcProviders.Names()
+		sort.Strings(providers)
+		c.JSON(http.StatusOK, gin.H{"success": true, "providers": providers})
+	}
+}
+
+// StartOAuthLogin returns the provider URL to send the user to. The frontend registered as the redirect URL
+// passes the returned code and state to CompleteOAuthLogin.
+func StartOAuthLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		startOAuth(ctx, c, nil)
+	}
+}
+
+// StartOAuthLink is StartOAuthLogin for a signed in user who wants to sign in with the provider from now on.
+// The callback links the provider account to the caller instead of matching it by email.
+func StartOAuthLink() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		user, ok := currentUser(ctx, c)
+		if !ok {
+			return
+		}
+		startOAuth(ctx, c, &user.User_ID)
+	}
+}
+
+// startOAuth records a single-use state for the login, bound to linkUserID when a signed in user is linking
+// the provider, and responds with the provider URL
+func startOAuth(ctx context.Context, c *gin.Context, linkUserID *primitive.ObjectID) {
+	providerName := c.Param("provider")
+	provider, err := oidcProviders.Provider(ctx, providerName)
+	if err != nil {
+		log.Printf("Error loading OIDC provider %s: %v", providerName, err)
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Login provider not available"})
+		return
+	}
+
+	state, stateErr := helpers.GenerateRandomToken(32)
+	nonce, nonceErr := helpers.GenerateRandomToken(32)
+	verifier, verifierErr := oidc.NewCodeVerifier()
+	if err := errors.Join(stateErr, nonceErr, verifierErr); err != nil {
+		log.Printf("Error generating OAuth state: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error starting login"})
+		return
+	}
+
+	oauthState := models.OAuthState{
+		State_Hash:    helpers.HashToken(state),
+		Provider:      providerName,
+		Nonce:         nonce,
+		Code_Verifier: verifier,
+		Expires_At:    time.Now().Add(oauthStateTTL),
+		Link_User_ID:  linkUserID,
+	}
+	if _, err := OAuthStateCollection.InsertOne(ctx, oauthState); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error starting login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "url": provider.AuthCodeURL(state, nonce, verifier)})
+}
+
+func CompleteOAuthLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var callbackData struct {
+			Code       string `json:"code" binding:"required"`
+			State      string `json:"state" binding:"required"`
+			InviteCode string `json:"invite_code"`
+		}
+		if err := c.BindJSON(&callbackData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		providerName := c.Param("provider")
+
+		// Each state can be used once, so a leaked callback URL cannot be replayed
+		var oauthState models.OAuthState
+		err := OAuthStateCollection.FindOneAndDelete(ctx, bson.M{
+			"_id":        helpers.HashToken(callbackData.State),
+			"provider":   providerName,
+			"expires_at": bson.M{"$gt": time.Now()},
+		}).Decode(&oauthState)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid or expired login state"})
+			return
+		}
+
+		provider, err := oidcProviders.Provider(ctx, providerName)
+		if err != nil {
+			log.Printf("Error loading OIDC provider %s: %v", providerName, err)
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Login provider not available"})
+			return
+		}
+
+		tokenResponse, err := provider.Exchange(ctx, callbackData.Code, oauthState.Code_Verifier)
+		if err != nil {
+			log.Printf("Error exchanging OAuth code: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Login with provider failed"})
+			return
+		}
+
+		claims, err := provider.VerifyIDToken(ctx, tokenResponse.IDToken, oauthState.Nonce)
+		if err != nil {
+			log.Printf("Error verifying ID token: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Login with provider failed"})
+			return
+		}
+
+		if oauthState.Link_User_ID != nil {
+			err := linkOAuthIdentity(ctx, *oauthState.Link_User_ID, providerName, claims)
+			if err == errOAuthIdentityLinkedOther {
+				c.JSON(http.StatusConflict, gin.H{"success": false, "error": "This provider account is already linked to another user"})
+				return
+			}
+			if err != nil {
+				log.Printf("Error linking OAuth identity: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"success": true, "message": "Provider linked successfully"})
+			return
+		}
+
+		user, err := findOrLinkOAuthUser(ctx, providerName, claims, callbackData.InviteCode)
+		if err != nil {
+			if errors.Is(err, errOAuthEmailNotVerified) {
+				c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "Your email address is not verified with the provider"})
+				return
+			}
+			if err == errOAuthLinkNeedsConfirm {
+				c.JSON(http.StatusConflict, gin.H{"success": false, "error": "An account with this email already exists. Sign in with your password and link the provider from your account settings"})
+				return
+			}
+			if err == errRegistrationClosed || err == errInviteRequired || err == errInviteInvalid {
+				registrationErrorResponse(c, err)
+				return
+			}
+			log.Printf("Error linking OAuth identity: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+			return
+		}
+
+		beginUserLogin(ctx, c, user)
+	}
+}
+
+// findOrLinkOAuthUser returns the user already linked to the provider account. Otherwise the identity is linked to
+// the user with the same email, or a new user is created, but only when the provider vouches for the email
+// and the registration mode admits them. Privileged and unverified accounts are never linked by email; their
+// owner has to link the provider while signed in.
+func findOrLinkOAuthUser(ctx context.Context, providerName string, claims *oidc.IDTokenClaims, inviteCode string) (models.User, error) {
+	var user models.User
+	err := UserCollection.FindOne(ctx, bson.M{
+		"identities": bson.M{"$elemMatch": bson.M{"provider": providerName, "subject": claims.Subject}},
+	}).Decode(&user)
+	if err == nil {
+		return user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return user, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return user, errOAuthEmailNotVerified
+	}
+
+	identity := models.Identity{
+		Provider:  providerName,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		Linked_At: time.Now(),
+	}
+
+	var existing models.User
+	err = UserCollection.FindOne(ctx, bson.M{"email": claims.Email}).Decode(&existing)
+	if err == nil {
+		permissions, err := middleware.RolePermissions(existing.Role)
+		if err != nil {
+			return user, err
+		}
+		if !oauthAutoLinkAllowed(existing.Verified, permissions) {
+			return user, errOAuthLinkNeedsConfirm
+		}
+
+		// Match the role and verification as well, so an account changed since the check above is not linked
+		err = UserCollection.FindOneAndUpdate(
+			ctx,
+			bson.M{"_id": existing.User_ID, "role": existing.Role, "verified": true},
+			bson.M{
+				"$push": bson.M{"identities": identity},
+				"$set":  bson.M{"updated_at": time.Now()},
+			},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&user)
+		if err == mongo.ErrNoDocuments {
+			return user, errOAuthLinkNeedsConfirm
+		}
+		return user, err
+	}
+	if err != mongo.ErrNoDocuments {
+		return user, err
+	}
+
+	// New users signing in with a provider have no password and can only sign in through a linked provider
+	user = models.User{
+		User_ID:    primitive.NewObjectID(),
+		Name:       claims.Name,
+		Email:      claims.Email,
+		Avatar:     claims.Picture,
+		Role:       models.RoleUser,
+		Verified:   true,
+		Identities: []models.Identity{identity},
+		Created_At: time.Now(),
+		Updated_At: time.Now(),
+	}
+
+	invite, releaseInvite, err := admitRegistration(ctx, inviteCode, user.User_ID, user.Email)
+	if err != nil {
+		return user, err
+	}
+	if invite != nil {
+		user.Role = invite.Role
+	}
+
+	if _, err := UserCollection.InsertOne(ctx, user); err != nil {
+		releaseInvite()
+		return user, err
+	}
+	return user, nil
+}
+
+// oauthAutoLinkAllowed reports whether an account can have a provider login linked to it by a matching email.
+// An unverified account may have been registered by someone else who knows its password, so linking it would
+// hand them the account once its owner signs in with the provider.
+func oauthAutoLinkAllowed(verified bool, permissions []string) bool {
+	if !verified {
+		return false
+	}
+	for _, permission := range oauthPrivilegedPermissions {
+		if middleware.HasPermission(permissions, permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// linkOAuthIdentity links the provider account to the signed in user who started the link. The email does not
+// have to match, since the user proved control of both accounts.
+func linkOAuthIdentity(ctx context.Context, userID primitive.ObjectID, providerName string, claims *oidc.IDTokenClaims) error {
+	var linked models.User
+	err := UserCollection.FindOne(ctx, bson.M{
+		"identities": bson.M{"$elemMatch": bson.M{"provider": providerName, "subject": claims.Subject}},
+	}).Decode(&linked)
+	if err == nil {
+		if linked.User_ID == userID {
+			return nil
+		}
+		return errOAuthIdentityLinkedOther
+	}
+	if err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	identity := models.Identity{
+		Provider:  providerName,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		Linked_At: time.Now(),
+	}
+	result, err := UserCollection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$push": bson.M{"identities": identity},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
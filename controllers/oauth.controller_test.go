@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"testing"
+
+	"portfolio/models"
+)
+
+func TestOAuthAutoLinkAllowed(t *testing.T) {
+	tests := []struct {
+		name        string
+		verified    bool
+		permissions []string
+		want        bool
+	}{
+		{"no permissions", true, nil, true},
+		{"ordinary user", true, []string{"expenses:read", "expenses:write"}, true},
+		{"unverified user", false, []string{"expenses:read", "expenses:write"}, false},
+		{"unverified without permissions", false, nil, false},
+		{"expense admin", true, models.ExpenseAdminPermissions, false},
+		{"portfolio admin", true, models.PortfolioAdminPermissions, false},
+		{"single admin permission", true, []string{"expenses:read", "users:impersonate"}, false},
+		{"portfolio editor", true, []string{"portfolio:edit"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := oauthAutoLinkAllowed(tt.verified, tt.permissions); got != tt.want {
+				t.Errorf("oauthAutoLinkAllowed(%v, %v) = %v, want %v", tt.verified, tt.permissions, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,432 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"portfolio/database"
+	"portfolio/helpers"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var PostCollection *mongo.Collection = database.PortfolioData(database.Client, "Posts")
+
+// postExcerptLength is the length of excerpts generated for posts saved without one
+const postExcerptLength = 200
+
+// postListProjection leaves the full body out of post lists
+var postListProjection = bson.M{"body": 0, "body_html": 0, "toc": 0, "rendered": 0}
+
+// publishedPostFilter matches published posts. Posts saved before statuses existed are published once their
+// publish date has passed.
+func publishedPostFilter() bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"status": models.StatusPublished},
+		bson.M{"status": bson.M{"$in": bson.A{"", nil}}, "published_at": bson.M{"$ne": nil, "$lte": time.Now()}},
+	}}
+}
+
+// renderPostBody derives the HTML, table of contents and reading time from a Markdown body, and an excerpt
+// when none is given
+func renderPostBody(body string, excerpt string) (models.RenderedPost, error) {
+	bodyHTML, headings, err := helpers.RenderMarkdown(body)
+	if err != nil {
+		return models.RenderedPost{}, err
+	}
+
+	rendered := models.RenderedPost{Body_HTML: bodyHTML, Excerpt: excerpt}
+	rendered.TOC = make([]models.TOCEntry, 0, len(headings))
+	for _, heading := range headings {
+		rendered.TOC = append(rendered.TOC, models.TOCEntry{Level: heading.Level, ID: heading.ID, Text: heading.Text})
+	}
+
+	plainText := helpers.PlainText(bodyHTML)
+	rendered.Reading_Time = helpers.ReadingTime(plainText)
+	if strings.TrimSpace(excerpt) == "" {
+		rendered.Excerpt = helpers.Excerpt(plainText, postExcerptLength)
+	}
+	return rendered, nil
+}
+
+// renderPost derives the HTML, table of contents, reading time, excerpt and normalized tags from the Markdown body,
+// and renders each translated body once here so serving a translation does not have to
+func renderPost(post *models.Post) error {
+	rendered, err := renderPostBody(post.Body, post.Excerpt)
+	if err != nil {
+		return err
+	}
+	post.Body_HTML = rendered.Body_HTML
+	post.TOC = rendered.TOC
+	post.Reading_Time = rendered.Reading_Time
+	post.Excerpt = rendered.Excerpt
+
+	post.Rendered = map[string]models.RenderedPost{}
+	for locale, translated := range post.Translations {
+		if translated["body"] == "" {
+			continue
+		}
+		rendered, err := renderPostBody(translated["body"], translated["excerpt"])
+		if err != nil {
+			return err
+		}
+		post.Rendered[locale] = rendered
+	}
+
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, tag := range post.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	post.Tags = tags
+	return nil
+}
+
+// EnsurePostIndexes makes slugs unique, so two posts saved at once cannot both claim the same slug
+func EnsurePostIndexes(ctx context.Context) error {
+	_, err := PostCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"slug": 1},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// uniquePostSlug returns the slug, numbered when another post already uses it
+func uniquePostSlug(ctx context.Context, slug string, postID primitive.ObjectID) (string, error) {
+	candidate := slug
+	for i := 2; ; i++ {
+		count, err := PostCollection.CountDocuments(ctx, bson.M{"slug": candidate, "_id": bson.M{"$ne": postID}})
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", slug, i)
+	}
+}
+
+// postSlugTakenResponse answers a save that lost a race for its slug to another post
+func postSlugTakenResponse(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{"success": false, "error": "Another post was just saved with this slug, please try again"})
+}
+
+// preparePost validates a post from a create or update request and fills in its derived fields
+func preparePost(ctx context.Context, c *gin.Context, post *models.Post) bool {
+	if strings.TrimSpace(post.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Title is required"})
+		return false
+	}
+
+	slug := helpers.Slugify(post.Slug)
+	if slug == "" {
+		slug = helpers.Slugify(post.Title)
+	}
+	if slug == "" {
+		// A title made only of symbols or emoji has nothing to slug, so the post falls back to its ID
+		slug = "post-" + post.Post_ID.Hex()
+	}
+
+	slug, err := uniquePostSlug(ctx, slug, post.Post_ID)
+	if err != nil {
+		log.Printf("Error checking post slug: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error saving post"})
+		return false
+	}
+	post.Slug = slug
+
+	if !validTranslations(c, post.Translations, postTranslatable) {
+		return false
+	}
+
+	status, ok := bindPublishStatus(c, &post.Status, post.Publish_At)
+	if !ok {
+		return false
+	}
+	post.Status = status
+	if post.Status == models.StatusPublished && post.Published_At == nil {
+		now := time.Now()
+		post.Published_At = &now
+	}
+
+	if err := renderPost(post); err != nil {
+		log.Printf("Error rendering post: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Error rendering Markdown"})
+		return false
+	}
+	return true
+}
+
+func CreatePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var post models.Post
+		if err := c.BindJSON(&post); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		post.Post_ID = primitive.NewObjectID()
+		if !preparePost(ctx, c, &post) {
+			return
+		}
+		post.Created_At = time.Now()
+		post.Updated_At = time.Now()
+
+		_, err := PostCollection.InsertOne(ctx, post)
+		if mongo.IsDuplicateKeyError(err) {
+			postSlugTakenResponse(c)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating post"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Post created successfully!", "post": post})
+	}
+}
+
+func UpdatePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postID := c.Param("id")
+		objID, err := primitive.ObjectIDFromHex(postID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid post ID"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var post models.Post
+		if err := c.BindJSON(&post); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		post.Post_ID = objID
+		statusGiven := post.Status != ""
+		if !preparePost(ctx, c, &post) {
+			return
+		}
+		post.Updated_At = time.Now()
+
+		fields := bson.M{
+			"title":        post.Title,
+			"slug":         post.Slug,
+			"excerpt":      post.Excerpt,
+			"body":         post.Body,
+			"body_html":    post.Body_HTML,
+			"toc":          post.TOC,
+			"cover_image":  post.Cover_Image,
+			"tags":         post.Tags,
+			"reading_time": post.Reading_Time,
+			"t1":           post.T1,
+			"t2":           post.T2,
+			"updated_at":   post.Updated_At,
+		}
+		// A request without a status keeps the current one, so clients that do not know about statuses
+		// cannot turn a published post back into a draft
+		if statusGiven {
+			fields["status"] = post.Status
+			fields["publish_at"] = post.Publish_At
+			fields["published_at"] = post.Published_At
+		}
+		// Translations left out of the request are kept, along with their rendered bodies
+		if post.Translations != nil {
+			fields["translations"] = post.Translations
+			fields["rendered"] = post.Rendered
+		}
+		update := bson.M{"$set": fields}
+
+		result, err := PostCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+		if mongo.IsDuplicateKeyError(err) {
+			postSlugTakenResponse(c)
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error updating post", "details": err.Error()})
+			return
+		}
+
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Post not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Post updated successfully"})
+	}
+}
+
+func DeletePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postID := c.Param("id")
+		objID, err := primitive.ObjectIDFromHex(postID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid post ID"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		result, err := PostCollection.DeleteOne(ctx, bson.M{"_id": objID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error deleting post"})
+			return
+		}
+
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Post not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Post deleted successfully"})
+	}
+}
+
+// listPosts responds with a page of posts matching the filter, newest first, optionally narrowed to a tag
+func listPosts(c *gin.Context, filter bson.M) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	if tag := strings.ToLower(strings.TrimSpace(c.Query("tag"))); tag != "" {
+		filter["tags"] = tag
+	}
+
+	page, limit := paginationParams(c)
+	opts := options.Find().
+		SetProjection(postListProjection).
+		SetSort(bson.D{{Key: "published_at", Value: -1}, {Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	total, err := PostCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving posts"})
+		return
+	}
+
+	posts := []models.Post{}
+	cursor, err := PostCollection.Find(ctx, filter, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving posts"})
+		return
+	}
+
+	if err = cursor.All(ctx, &posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error decoding posts"})
+		return
+	}
+
+	locale := requestLocale(c)
+	for i := range posts {
+		localize(locale, &posts[i], &posts[i].Translations)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "posts": posts, "total": total, "page": page, "limit": limit})
+}
+
+// GetAllPosts lists published posts
+func GetAllPosts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listPosts(c, publishedPostFilter())
+	}
+}
+
+// GetAllPostsAdmin lists every post, including those not yet published
+func GetAllPostsAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		listPosts(c, bson.M{})
+	}
+}
+
+// GetPostBySlug returns a published post
+func GetPostBySlug() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := publishedPostFilter()
+		filter["slug"] = c.Param("slug")
+
+		var post models.Post
+		err := PostCollection.FindOne(ctx, filter).Decode(&post)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Post not found"})
+				return
+			}
+			log.Printf("Error retrieving post: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving post"})
+			return
+		}
+
+		locale := requestLocale(c)
+		translated := post.Translations[locale]
+		localize(locale, &post, &post.Translations)
+		if translated["body"] != "" {
+			// The HTML, table of contents and excerpt stored with the post come from the default locale body.
+			// Posts saved before translations were rendered on save are rendered here until they are saved again.
+			rendered, ok := post.Rendered[locale]
+			if !ok {
+				var err error
+				if rendered, err = renderPostBody(translated["body"], translated["excerpt"]); err != nil {
+					log.Printf("Error rendering post: %v", err)
+					c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving post"})
+					return
+				}
+			}
+			post.Body_HTML = rendered.Body_HTML
+			post.TOC = rendered.TOC
+			post.Reading_Time = rendered.Reading_Time
+			post.Excerpt = rendered.Excerpt
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "post": post})
+	}
+}
+
+func GetOnePostAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		postID := c.Param("id")
+		objID, err := primitive.ObjectIDFromHex(postID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid post ID"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var post models.Post
+		err = PostCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&post)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Post not found"})
+				return
+			}
+			log.Printf("Error retrieving post: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving post"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "post": post})
+	}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"portfolio/models"
+)
+
+func TestRenderPostTranslations(t *testing.T) {
+	post := models.Post{
+		Body: "# Hello\n\nEnglish text.",
+		Translations: models.Translations{
+			"my": {"title": "Title only"},
+			"fr": {"body": "# Bonjour\n\nTexte français.", "excerpt": "Résumé"},
+			"de": {"body": "# Hallo\n\nDeutscher Text."},
+		},
+	}
+	if err := renderPost(&post); err != nil {
+		t.Fatalf("renderPost: %v", err)
+	}
+
+	if !strings.Contains(post.Body_HTML, "Hello") || strings.Contains(post.Body_HTML, "Bonjour") {
+		t.Errorf("Body_HTML = %q, want the default locale body", post.Body_HTML)
+	}
+	if _, ok := post.Rendered["my"]; ok {
+		t.Error("a translation without a body was rendered")
+	}
+
+	fr, ok := post.Rendered["fr"]
+	if !ok {
+		t.Fatal("French body was not rendered")
+	}
+	if !strings.Contains(fr.Body_HTML, "Bonjour") {
+		t.Errorf("French Body_HTML = %q", fr.Body_HTML)
+	}
+	if len(fr.TOC) != 1 || fr.TOC[0].Text != "Bonjour" {
+		t.Errorf("French TOC = %v", fr.TOC)
+	}
+	if fr.Excerpt != "Résumé" {
+		t.Errorf("French excerpt = %q, want the translated excerpt", fr.Excerpt)
+	}
+
+	if de := post.Rendered["de"]; !strings.Contains(de.Excerpt, "Deutscher Text") {
+		t.Errorf("German excerpt = %q, want one generated from the German body", de.Excerpt)
+	}
+}
+
+func TestRenderPostBodySanitizes(t *testing.T) {
+	rendered, err := renderPostBody("<script>alert(1)</script>\n\nText", "")
+	if err != nil {
+		t.Fatalf("renderPostBody: %v", err)
+	}
+	if strings.Contains(rendered.Body_HTML, "<script") {
+		t.Errorf("Body_HTML = %q, want scripts removed", rendered.Body_HTML)
+	}
+}
@@ -2,8 +2,11 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"portfolio/database"
@@ -13,10 +16,93 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var ProjectCollection *mongo.Collection = database.PortfolioData(database.Client, "Projects")
 
+var errProjectNotFound = errors.New("project not found")
+
+// errCodeIllegalOperation is the server error for a transaction started on a standalone MongoDB
+const errCodeIllegalOperation = 20
+
+// CounterCollection holds named counters that are raised atomically
+var CounterCollection *mongo.Collection = database.PortfolioData(database.Client, "Counters")
+
+// projectSort lists projects in their chosen order, newest first among those never ordered
+var projectSort = bson.D{{Key: "sort_order", Value: 1}, {Key: "created_at", Value: -1}}
+
+// normalizeProjectTags trims and de-duplicates a project's tags, taking the single Tag from older clients
+// when no tags are sent, and keeps Tag in step with the first tag
+func normalizeProjectTags(project *models.Project) {
+	tags := project.Tags
+	if len(tags) == 0 && project.Tag != nil {
+		tags = []string{*project.Tag}
+	}
+
+	seen := make(map[string]bool)
+	project.Tags = []string{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		project.Tags = append(project.Tags, tag)
+	}
+
+	project.Tag = nil
+	if len(project.Tags) > 0 {
+		project.Tag = &project.Tags[0]
+	}
+}
+
+// projectSortOrderCounter is the Counters document holding the last sort order given to a new project
+const projectSortOrderCounter = "project_sort_order"
+
+// nextProjectSortOrder places new projects after every existing one. The position comes from a counter raised
+// atomically past the highest existing sort order, so projects created at the same time never share one.
+func nextProjectSortOrder(ctx context.Context) (int, error) {
+	highest := -1
+	var last models.Project
+	err := ProjectCollection.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "sort_order", Value: -1}})).Decode(&last)
+	if err == nil {
+		highest = last.Sort_Order
+	} else if err != mongo.ErrNoDocuments {
+		return 0, err
+	}
+
+	var counter struct {
+		Value int `bson:"value"`
+	}
+	err = CounterCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": projectSortOrderCounter},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"value": bson.M{"$add": bson.A{bson.M{"$max": bson.A{bson.M{"$ifNull": bson.A{"$value", -1}}, highest}}, 1}},
+		}}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	return counter.Value, err
+}
+
+// projectTagFilter matches a tag whatever its case, since tags are de-duplicated without regard to case but
+// stored as they were typed
+func projectTagFilter(tag string) primitive.Regex {
+	return primitive.Regex{Pattern: "^" + regexp.QuoteMeta(strings.TrimSpace(tag)) + "$", Options: "i"}
+}
+
+// MigrateProjectTags copies the single tag of projects saved before tags existed into their tags
+func MigrateProjectTags(ctx context.Context) error {
+	_, err := ProjectCollection.UpdateMany(
+		ctx,
+		bson.M{"tags": bson.M{"$exists": false}, "tag": bson.M{"$nin": bson.A{nil, ""}}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"tags": bson.A{"$tag"}}}}},
+	)
+	return err
+}
+
 func CreateProject() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
@@ -28,11 +114,29 @@ func CreateProject() gin.HandlerFunc {
 			return
 		}
 
+		status, ok := bindPublishStatus(c, project.Status, project.Publish_At)
+		if !ok {
+			return
+		}
+		project.Status = &status
+		if !validTranslations(c, project.Translations, projectTranslatable) {
+			return
+		}
+		normalizeProjectTags(&project)
+
+		sortOrder, err := nextProjectSortOrder(ctx)
+		if err != nil {
+			log.Printf("Error ordering project: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating project"})
+			return
+		}
+
 		project.Project_ID = primitive.NewObjectID()
+		project.Sort_Order = sortOrder
 		project.Created_At = time.Now()
 		project.Updated_At = time.Now()
 
-		_, err := ProjectCollection.InsertOne(ctx, project)
+		_, err = ProjectCollection.InsertOne(ctx, project)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating project"})
 			return
@@ -60,22 +164,39 @@ func UpdateProject() gin.HandlerFunc {
 			return
 		}
 
+		normalizeProjectTags(&project)
 		project.Updated_At = time.Now()
 
-		update := bson.M{
-			"$set": bson.M{
-				"title":       project.Title,
-				"description": project.Description,
-				"role":        project.Role,
-				"demo_link":   project.DemoLink,
-				"code_link":   project.CodeLink,
-				"tag":         project.Tag,
-				"image":       project.Image,
-				"t1":          project.T1,
-				"t2":          project.T2,
-				"updated_at":  project.Updated_At,
-			},
+		fields := bson.M{
+			"title":       project.Title,
+			"description": project.Description,
+			"role":        project.Role,
+			"demo_link":   project.DemoLink,
+			"code_link":   project.CodeLink,
+			"tag":         project.Tag,
+			"tags":        project.Tags,
+			"featured":    project.Featured,
+			"image":       project.Image,
+			"t1":          project.T1,
+			"t2":          project.T2,
+			"updated_at":  project.Updated_At,
+		}
+		// Translations left out of the request are kept
+		if project.Translations != nil {
+			if !validTranslations(c, project.Translations, projectTranslatable) {
+				return
+			}
+			fields["translations"] = project.Translations
 		}
+		if project.Status != nil {
+			status, ok := bindPublishStatus(c, project.Status, project.Publish_At)
+			if !ok {
+				return
+			}
+			fields["status"] = status
+			fields["publish_at"] = project.Publish_At
+		}
+		update := bson.M{"$set": fields}
 
 		result, err := ProjectCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
 		if err != nil {
@@ -124,8 +245,16 @@ func GetAllProjects() gin.HandlerFunc {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
+		filter := visibleContentFilter(c, bson.M{})
+		if tag := strings.TrimSpace(c.Query("tag")); tag != "" {
+			filter["tags"] = projectTagFilter(tag)
+		}
+		if c.Query("featured") == "true" {
+			filter["featured"] = true
+		}
+
 		var projects []models.Project
-		cursor, err := ProjectCollection.Find(ctx, bson.M{})
+		cursor, err := ProjectCollection.Find(ctx, filter, options.Find().SetSort(projectSort))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving projects"})
 			return
@@ -136,7 +265,19 @@ func GetAllProjects() gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"success": true, "projects": projects})
+		locale := requestLocale(c)
+		for i := range projects {
+			localize(locale, &projects[i], &projects[i].Translations)
+		}
+
+		tags, err := projectTagCounts(ctx, visibleContentFilter(c, bson.M{}))
+		if err != nil {
+			log.Printf("Error counting project tags: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving projects"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "projects": projects, "tags": tags})
 	}
 }
 
@@ -153,13 +294,149 @@ func GetOneProject() gin.HandlerFunc {
 		defer cancel()
 
 		var project models.Project
-		err = ProjectCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&project)
+		err = ProjectCollection.FindOne(ctx, visibleContentFilter(c, bson.M{"_id": objID})).Decode(&project)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Project not found"})
+			return
+		}
 		if err != nil {
 			log.Printf("Error retrieving project: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving project", "details": err.Error()})
 			return
 		}
 
+		localize(requestLocale(c), &project, &project.Translations)
+
 		c.JSON(http.StatusOK, gin.H{"success": true, "project": project})
 	}
 }
+
+// projectTagCounts counts the projects matching filter under each tag, most used first. Tags are counted
+// ignoring case, the same way projectTagFilter matches them.
+func projectTagCounts(ctx context.Context, filter bson.M) ([]models.TagCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: projectTagGroup}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := ProjectCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	tags := []models.TagCount{}
+	if err := cursor.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// projectTagGroup groups unwound project tags case-insensitively and counts them
+var projectTagGroup = bson.M{"_id": bson.M{"$toLower": "$tags"}, "count": bson.M{"$sum": 1}}
+
+// applyProjectOrder gives the listed projects the first positions, in order, and the rest the following ones
+// in their current order
+func applyProjectOrder(ctx context.Context, listed []primitive.ObjectID) error {
+	count, err := ProjectCollection.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": listed}})
+	if err != nil {
+		return err
+	}
+	if count != int64(len(listed)) {
+		return errProjectNotFound
+	}
+
+	var rest []models.Project
+	cursor, err := ProjectCollection.Find(ctx, bson.M{"_id": bson.M{"$nin": listed}},
+		options.Find().SetSort(projectSort).SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	if err := cursor.All(ctx, &rest); err != nil {
+		return err
+	}
+
+	order := append([]primitive.ObjectID{}, listed...)
+	for _, project := range rest {
+		order = append(order, project.Project_ID)
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(order))
+	for i, objID := range order {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": objID}).
+			SetUpdate(bson.M{"$set": bson.M{"sort_order": i}}))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+	_, err = ProjectCollection.BulkWrite(ctx, writes)
+	return err
+}
+
+// transactionsUnsupported reports whether the server refused a transaction because it is a standalone MongoDB
+func transactionsUnsupported(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(errCodeIllegalOperation)
+}
+
+// ReorderProjects sets the display order to the order of the given project IDs in a single transaction.
+// Projects left out keep their relative order after the listed ones. Only a server that cannot run
+// transactions gets the positions written without one.
+func ReorderProjects() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var reorderData struct {
+			IDs []string `json:"ids" binding:"required"`
+		}
+		if err := c.BindJSON(&reorderData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		objIDs := make([]primitive.ObjectID, 0, len(reorderData.IDs))
+		seen := make(map[primitive.ObjectID]bool)
+		for _, id := range reorderData.IDs {
+			objID, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid project ID: " + id})
+				return
+			}
+			if seen[objID] {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Project listed more than once: " + id})
+				return
+			}
+			seen[objID] = true
+			objIDs = append(objIDs, objID)
+		}
+
+		session, err := database.Client.StartSession()
+		if err != nil {
+			log.Printf("Error starting session: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error reordering projects"})
+			return
+		}
+		defer session.EndSession(ctx)
+
+		_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+			return nil, applyProjectOrder(sc, objIDs)
+		})
+		if transactionsUnsupported(err) {
+			log.Printf("Transactions are not supported, reordering projects without one")
+			err = applyProjectOrder(ctx, objIDs)
+		}
+		if err == errProjectNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "One or more projects were not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("Error reordering projects: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error reordering projects"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Projects reordered successfully"})
+	}
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestProjectTagFilter(t *testing.T) {
+	tests := []struct {
+		query  string
+		stored string
+		want   bool
+	}{
+		{"go", "Go", true},
+		{"  GO ", "Go", true},
+		{"Go", "Golang", false},
+		{"c++", "C++", true},
+		{"c++", "cc", false},
+		{".*", "Go", false},
+	}
+
+	for _, tt := range tests {
+		filter := projectTagFilter(tt.query)
+		pattern := regexp.MustCompile("(?" + filter.Options + ")" + filter.Pattern)
+		if got := pattern.MatchString(tt.stored); got != tt.want {
+			t.Errorf("tag %q matching %q = %v, want %v", tt.query, tt.stored, got, tt.want)
+		}
+	}
+}
+
+func TestProjectTagGroupIgnoresCase(t *testing.T) {
+	if got := projectTagGroup["_id"]; !reflect.DeepEqual(got, bson.M{"$toLower": "$tags"}) {
+		t.Errorf("tags grouped by %v, want them lowercased", got)
+	}
+}
+
+func TestTransactionsUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"standalone server", mongo.CommandError{Code: errCodeIllegalOperation, Message: "Transaction numbers are only allowed on a replica set member or mongos"}, true},
+		{"wrapped", fmt.Errorf("reorder: %w", mongo.CommandError{Code: errCodeIllegalOperation}), true},
+		{"write conflict", mongo.CommandError{Code: 112, Labels: []string{"TransientTransactionError"}}, false},
+		{"project not found", errProjectNotFound, false},
+		{"no error", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := transactionsUnsupported(tt.err); got != tt.want {
+			t.Errorf("%s: transactionsUnsupported = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"portfolio/middleware"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// scheduledPublishInterval is how often scheduled content is checked for being due
+const scheduledPublishInterval = time.Minute
+
+// resolvePublishStatus validates a requested status, defaulting to draft. Content scheduled for a time that has
+// already passed is published straight away.
+func resolvePublishStatus(status string, publishAt *time.Time) (string, error) {
+	if status == "" {
+		return models.StatusDraft, nil
+	}
+	if !models.IsValidContentStatus(status) {
+		return "", errors.New("status must be draft, scheduled, published or archived")
+	}
+	if status == models.StatusScheduled {
+		if publishAt == nil {
+			return "", errors.New("publish_at is required to schedule content")
+		}
+		if !publishAt.After(time.Now()) {
+			return models.StatusPublished, nil
+		}
+	}
+	return status, nil
+}
+
+// bindPublishStatus resolves the status of a create or update request, responding with the reason it is invalid
+func bindPublishStatus(c *gin.Context, status *string, publishAt *time.Time) (string, bool) {
+	requested := ""
+	if status != nil {
+		requested = *status
+	}
+	resolved, err := resolvePublishStatus(requested, publishAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return "", false
+	}
+	return resolved, true
+}
+
+// canViewUnpublished reports whether the caller may see drafts, scheduled and archived content
+func canViewUnpublished(c *gin.Context) bool {
+	return middleware.CallerHasPermission(c, models.PermissionPortfolioEdit)
+}
+
+// publishedContentFilter matches published projects, services, certificates and resume entries.
+// Content saved before statuses existed has none and stays public.
+func publishedContentFilter() bson.M {
+	return bson.M{"status": bson.M{"$in": bson.A{models.StatusPublished, nil}}}
+}
+
+// visibleContentFilter limits the filter to published content unless the caller manages the portfolio
+func visibleContentFilter(c *gin.Context, filter bson.M) bson.M {
+	if canViewUnpublished(c) {
+		return filter
+	}
+	for key, value := range publishedContentFilter() {
+		filter[key] = value
+	}
+	return filter
+}
+
+// StartScheduledPublisher publishes scheduled projects, services, certificates, resume entries and posts once
+// their publish time passes, checking every scheduledPublishInterval until ctx is done
+func StartScheduledPublisher(ctx context.Context) {
+	ticker := time.NewTicker(scheduledPublishInterval)
+	defer ticker.Stop()
+
+	for {
+		publishScheduledContent(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func publishScheduledContent(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"status": models.StatusScheduled, "publish_at": bson.M{"$lte": now}}
+	publish := bson.M{"status": models.StatusPublished, "updated_at": now}
+
+	collections := []*mongo.Collection{
+		ProjectCollection, ServiceCollection, CertificateCollection,
+		ExperienceCollection, EducationCollection, SkillCollection,
+	}
+	for _, collection := range collections {
+		if _, err := collection.UpdateMany(ctx, filter, bson.M{"$set": publish}); err != nil {
+			log.Printf("Error publishing scheduled %s: %v", collection.Name(), err)
+		}
+	}
+
+	// Posts also record when they went live
+	postUpdate := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"status":       models.StatusPublished,
+			"published_at": bson.M{"$ifNull": bson.A{"$published_at", "$publish_at"}},
+			"updated_at":   now,
+		}}},
+	}
+	if _, err := PostCollection.UpdateMany(ctx, filter, postUpdate); err != nil {
+		log.Printf("Error publishing scheduled posts: %v", err)
+	}
+}
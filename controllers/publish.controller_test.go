@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"portfolio/models"
+)
+
+func TestResolvePublishStatus(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		status    string
+		publishAt *time.Time
+		want      string
+		wantErr   bool
+	}{
+		{"defaults to draft", "", nil, models.StatusDraft, false},
+		{"published", models.StatusPublished, nil, models.StatusPublished, false},
+		{"archived", models.StatusArchived, nil, models.StatusArchived, false},
+		{"scheduled in the future", models.StatusScheduled, &future, models.StatusScheduled, false},
+		{"scheduled in the past publishes now", models.StatusScheduled, &past, models.StatusPublished, false},
+		{"scheduled without a time", models.StatusScheduled, nil, "", true},
+		{"unknown status", "live", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolvePublishStatus(tt.status, tt.publishAt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolvePublishStatus error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolvePublishStatus = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"portfolio/database"
+	"portfolio/helpers"
+	"portfolio/models"
+	token "portfolio/tokens"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var RefreshTokenCollection *mongo.Collection = database.PortfolioData(database.Client, "RefreshTokens")
+
+// Refresh tokens are bound to the login endpoint that issued them
+const (
+	ClientPortfolio = "portfolio"
+	ClientExpense   = "expense"
+)
+
+var errRefreshTokenInvalid = errors.New("invalid refresh token")
+
+// issueTokenPair signs an access token and stores a new opaque refresh token.
+// The session ID doubles as the refresh token family, so revoking a session revokes its tokens.
+func issueTokenPair(ctx context.Context, client string, sessionID string, email string, userID string, role string) (accessToken string, refreshToken string, err error) {
+	accessToken, err = token.TokenGenerator(email, userID, role, sessionID)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err = helpers.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	record := models.RefreshToken{
+		Token_ID:   primitive.NewObjectID(),
+		Token_Hash: helpers.HashToken(refreshToken),
+		Family_ID:  sessionID,
+		Client:     client,
+		User_ID:    userID,
+		Email:      email,
+		Role:       role,
+		Expires_At: time.Now().Add(token.RefreshTokenTTL()),
+		Created_At: time.Now(),
+	}
+	if _, err = RefreshTokenCollection.InsertOne(ctx, record); err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// revokeTokenFamilies revokes every refresh token descended from the given logins
+func revokeTokenFamilies(ctx context.Context, familyIDs []string) error {
+	_, err := RefreshTokenCollection.UpdateMany(
+		ctx,
+		bson.M{"family_id": bson.M{"$in": familyIDs}, "revoked": false},
+		bson.M{"$set": bson.M{"revoked": true}},
+	)
+	return err
+}
+
+// rotateRefreshToken consumes a refresh token and issues a new pair in the same family.
+// Presenting a token that was already rotated is treated as theft and revokes the whole session.
+func rotateRefreshToken(ctx context.Context, client string, refreshToken string) (string, string, error) {
+	var record models.RefreshToken
+	err := RefreshTokenCollection.FindOne(ctx, bson.M{"token_hash": helpers.HashToken(refreshToken), "client": client}).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return "", "", errRefreshTokenInvalid
+		}
+		return "", "", err
+	}
+
+	if record.Revoked {
+		if record.Replaced_By != "" {
+			log.Printf("Refresh token reuse detected for family %s", record.Family_ID)
+			if err := revokeSessions(ctx, bson.M{"_id": sessionObjectID(record.Family_ID)}); err != nil {
+				return "", "", err
+			}
+		}
+		return "", "", errRefreshTokenInvalid
+	}
+
+	if time.Now().After(record.Expires_At) {
+		return "", "", errRefreshTokenInvalid
+	}
+
+	email, role := record.Email, record.Role
+	if record.User_ID != "" {
+		userID, err := primitive.ObjectIDFromHex(record.User_ID)
+		if err != nil {
+			return "", "", errRefreshTokenInvalid
+		}
+		var user models.User
+		if err := UserCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return "", "", errRefreshTokenInvalid
+			}
+			return "", "", err
+		}
+		if user.Suspended {
+			return "", "", errRefreshTokenInvalid
+		}
+		email, role = user.Email, user.Role
+	}
+
+	accessToken, newRefreshToken, err := issueTokenPair(ctx, client, record.Family_ID, email, record.User_ID, role)
+	if err != nil {
+		return "", "", err
+	}
+	touchSession(ctx, record.Family_ID)
+
+	// Only one caller can win the rotation; a concurrent use of the same token counts as reuse
+	result, err := RefreshTokenCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": record.Token_ID, "revoked": false},
+		bson.M{"$set": bson.M{"revoked": true, "replaced_by": helpers.HashToken(newRefreshToken)}},
+	)
+	if err != nil {
+		return "", "", err
+	}
+	if result.MatchedCount == 0 {
+		log.Printf("Refresh token reuse detected for family %s", record.Family_ID)
+		if err := revokeSessions(ctx, bson.M{"_id": sessionObjectID(record.Family_ID)}); err != nil {
+			return "", "", err
+		}
+		return "", "", errRefreshTokenInvalid
+	}
+
+	return accessToken, newRefreshToken, nil
+}
+
+func RefreshToken(client string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var refreshData struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
+		}
+		if err := c.BindJSON(&refreshData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		accessToken, refreshToken, err := rotateRefreshToken(ctx, client, refreshData.RefreshToken)
+		if err != nil {
+			if err == errRefreshTokenInvalid {
+				c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid or expired refresh token"})
+				return
+			}
+			log.Printf("Error refreshing token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error refreshing token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":      true,
+			"accessToken":  accessToken,
+			"refreshToken": refreshToken,
+		})
+	}
+}
+
+func Logout(client string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var logoutData struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
+		}
+		if err := c.BindJSON(&logoutData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		var record models.RefreshToken
+		err := RefreshTokenCollection.FindOne(ctx, bson.M{"token_hash": helpers.HashToken(logoutData.RefreshToken), "client": client}).Decode(&record)
+		if err != nil && err != mongo.ErrNoDocuments {
+			log.Printf("Error finding refresh token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error logging out"})
+			return
+		}
+
+		if err == nil {
+			if err := revokeSessions(ctx, bson.M{"_id": sessionObjectID(record.Family_ID)}); err != nil {
+				log.Printf("Error revoking session: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error logging out"})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Logged out successfully"})
+	}
+}
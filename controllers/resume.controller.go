@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Display order of resume entries. Entries keep sort_order 0 unless an admin pins them; equal orders fall
+// back to the newest start date, and skills to their category and strongest first.
+var (
+	resumeEntrySort = bson.D{{Key: "sort_order", Value: 1}, {Key: "start_date", Value: -1}, {Key: "created_at", Value: -1}}
+	skillSort       = bson.D{{Key: "category", Value: 1}, {Key: "sort_order", Value: 1}, {Key: "proficiency", Value: -1}, {Key: "name", Value: 1}}
+)
+
+// normalizeHighlights trims highlights and drops the empty ones
+func normalizeHighlights(highlights []string) []string {
+	normalized := []string{}
+	for _, highlight := range highlights {
+		if highlight = strings.TrimSpace(highlight); highlight != "" {
+			normalized = append(normalized, highlight)
+		}
+	}
+	return normalized
+}
+
+// checkDateRange requires a start date, and an end date, when there is one, that is not before it
+func checkDateRange(start time.Time, end *time.Time) error {
+	if start.IsZero() {
+		return errors.New("start_date is required")
+	}
+	if end != nil && end.Before(start) {
+		return errors.New("end_date cannot be before start_date")
+	}
+	return nil
+}
+
+// GetResume returns experience, education and skills together, each in display order. Signed in editors
+// also see entries that are not published.
+func GetResume() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		experience, err := findExperience(ctx, c)
+		if err != nil {
+			log.Printf("Error retrieving experience: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving resume"})
+			return
+		}
+
+		education, err := findEducation(ctx, c)
+		if err != nil {
+			log.Printf("Error retrieving education: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving resume"})
+			return
+		}
+
+		skills, err := findSkills(ctx, c, bson.M{})
+		if err != nil {
+			log.Printf("Error retrieving skills: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving resume"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "experience": experience, "education": education, "skills": skills})
+	}
+}
+
+// findExperience lists the experience the caller may see, in display order and in the requested locale
+func findExperience(ctx context.Context, c *gin.Context) ([]models.Experience, error) {
+	experience := []models.Experience{}
+	cursor, err := ExperienceCollection.Find(ctx, visibleContentFilter(c, bson.M{}), options.Find().SetSort(resumeEntrySort))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &experience); err != nil {
+		return nil, err
+	}
+
+	locale := requestLocale(c)
+	for i := range experience {
+		localize(locale, &experience[i], &experience[i].Translations)
+	}
+	return experience, nil
+}
+
+// findEducation lists the education the caller may see, in display order and in the requested locale
+func findEducation(ctx context.Context, c *gin.Context) ([]models.Education, error) {
+	education := []models.Education{}
+	cursor, err := EducationCollection.Find(ctx, visibleContentFilter(c, bson.M{}), options.Find().SetSort(resumeEntrySort))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &education); err != nil {
+		return nil, err
+	}
+
+	locale := requestLocale(c)
+	for i := range education {
+		localize(locale, &education[i], &education[i].Translations)
+	}
+	return education, nil
+}
+
+// findSkills lists the skills matching the filter that the caller may see, in display order and in the
+// requested locale
+func findSkills(ctx context.Context, c *gin.Context, filter bson.M) ([]models.Skill, error) {
+	skills := []models.Skill{}
+	cursor, err := SkillCollection.Find(ctx, visibleContentFilter(c, filter), options.Find().SetSort(skillSort))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &skills); err != nil {
+		return nil, err
+	}
+
+	locale := requestLocale(c)
+	for i := range skills {
+		localize(locale, &skills[i], &skills[i].Translations)
+	}
+	return skills, nil
+}
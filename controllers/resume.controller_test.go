@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"portfolio/helpers"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestResumeTranslatableFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		fields []string
+	}{
+		{"experience", &models.Experience{}, experienceTranslatable},
+		{"education", &models.Education{}, educationTranslatable},
+		{"skill", &models.Skill{}, skillTranslatable},
+	}
+
+	for _, tt := range tests {
+		for _, name := range tt.fields {
+			field, ok := helpers.JSONFieldValue(tt.value, name)
+			if !ok || field.Kind() != reflect.String {
+				t.Errorf("%s: %q is not a string field, so it cannot be translated", tt.name, name)
+			}
+		}
+	}
+}
+
+func TestResumeEntriesHiddenFromVisitors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	filter := visibleContentFilter(c, bson.M{"_id": "entry"})
+	if !reflect.DeepEqual(filter["status"], publishedContentFilter()["status"]) {
+		t.Errorf("filter = %v, want only published entries for a visitor", filter)
+	}
+	if filter["_id"] != "entry" {
+		t.Errorf("filter = %v, want the requested entry kept", filter)
+	}
+}
+
+func TestLocalizeResumeEntry(t *testing.T) {
+	t.Setenv("SUPPORTED_LOCALES", "en,my")
+
+	skill := models.Skill{
+		Name:         "Go",
+		Category:     "Languages",
+		Translations: models.Translations{"my": {"category": "ဘာသာစကား"}},
+	}
+	localize("my", &skill, &skill.Translations)
+
+	if skill.Name != "Go" || skill.Category != "ဘာသာစကား" {
+		t.Errorf("skill = %+v, want the translated category and the default name", skill)
+	}
+	if skill.Translations != nil {
+		t.Error("localize kept the translations")
+	}
+}
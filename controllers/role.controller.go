@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"portfolio/database"
+	"portfolio/middleware"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var RoleCollection *mongo.Collection = database.PortfolioData(database.Client, "Roles")
+
+var defaultRoles = []models.Role{
+	{
+		Name:        models.RoleUser,
+		Description: "Expense app user",
+		Permissions: []string{models.PermissionExpensesRead, models.PermissionExpensesWrite},
+	},
+	{
+		Name:        models.RoleAdmin,
+		Description: "Manages expense app users",
+		Permissions: []string{models.PermissionExpensesRead, models.PermissionExpensesWrite, models.PermissionUsersRead, models.PermissionUsersWrite},
+	},
+	{
+		Name:        models.RoleSuperAdmin,
+		Description: "Full access, including roles and settings",
+		Permissions: models.AllPermissions,
+	},
+	{
+		Name:        models.RolePortfolioAdmin,
+		Description: "Manages the portfolio content",
+		Permissions: []string{models.PermissionExpensesRead, models.PermissionExpensesWrite, models.PermissionPortfolioEdit, models.PermissionMessagesRead, models.PermissionVisitorsRead},
+	},
+}
+
+// legacyRoles maps the numeric roles stored before named roles existed
+var legacyRoles = map[int]string{
+	0: models.RoleUser,
+	1: models.RoleAdmin,
+	2: models.RoleSuperAdmin,
+	3: models.RolePortfolioAdmin,
+}
+
+// SeedRoles creates the built-in roles when missing and migrates numeric roles to role names.
+// It is safe to run on every startup. The super admin role is kept in sync with AllPermissions so
+// permissions added later are granted to it.
+func SeedRoles(ctx context.Context) error {
+	for _, role := range defaultRoles {
+		role.System = true
+		role.Created_At = time.Now()
+		role.Updated_At = time.Now()
+
+		update := bson.M{"$setOnInsert": role}
+		if role.Name == models.RoleSuperAdmin {
+			update = bson.M{
+				"$setOnInsert": bson.M{"description": role.Description, "system": true, "created_at": role.Created_At, "updated_at": role.Updated_At},
+				"$set":         bson.M{"permissions": models.AllPermissions},
+			}
+		}
+
+		_, err := RoleCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": role.Name},
+			update,
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for legacyRole, roleName := range legacyRoles {
+		for _, collection := range []*mongo.Collection{UserCollection, RefreshTokenCollection} {
+			_, err := collection.UpdateMany(ctx, bson.M{"role": legacyRole}, bson.M{"$set": bson.M{"role": roleName}})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// callerPermissions returns the permissions of the authenticated caller's role
+func callerPermissions(c *gin.Context) ([]string, error) {
+	return middleware.RolePermissions(c.GetString("role"))
+}
+
+// canGrant reports whether every permission is held by the caller, so nobody can hand out more than they have
+func canGrant(callerPermissions []string, permissions []string) bool {
+	for _, permission := range permissions {
+		if !middleware.HasPermission(callerPermissions, permission) {
+			return false
+		}
+	}
+	return true
+}
+
+func GetAllRoles() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var roles []models.Role
+		cursor, err := RoleCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving roles"})
+			return
+		}
+
+		if err = cursor.All(ctx, &roles); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error decoding roles"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "roles": roles, "permissions": models.AllPermissions})
+	}
+}
+
+// bindRolePermissions validates the permission list of a create or update request
+func bindRolePermissions(c *gin.Context, permissions []string) bool {
+	for _, permission := range permissions {
+		if !models.IsValidPermission(permission) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Unknown permission: " + permission})
+			return false
+		}
+	}
+
+	callerPerms, err := callerPermissions(c)
+	if err != nil {
+		log.Printf("Error retrieving role permissions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error checking permissions"})
+		return false
+	}
+	if !canGrant(callerPerms, permissions) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "Cannot grant permissions you do not have"})
+		return false
+	}
+	return true
+}
+
+func CreateRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var role models.Role
+		if err := c.BindJSON(&role); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		if role.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Role name is required"})
+			return
+		}
+		if !bindRolePermissions(c, role.Permissions) {
+			return
+		}
+
+		role.System = false
+		role.Created_At = time.Now()
+		role.Updated_At = time.Now()
+
+		_, err := RoleCollection.InsertOne(ctx, role)
+		if err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				c.JSON(http.StatusConflict, gin.H{"success": false, "error": "Role already exists"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating role"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Role created successfully", "role": role})
+	}
+}
+
+func UpdateRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		roleName := c.Param("name")
+
+		var roleData struct {
+			Description *string  `json:"description"`
+			Permissions []string `json:"permissions"`
+		}
+		if err := c.BindJSON(&roleData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		var existingRole models.Role
+		if err := RoleCollection.FindOne(ctx, bson.M{"_id": roleName}).Decode(&existingRole); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Role not found"})
+			return
+		}
+		// Built-in roles are reseeded on startup, and changing super_admin could lock every admin out
+		if existingRole.System {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Built-in roles cannot be changed"})
+			return
+		}
+
+		// Editing a role also takes away its current permissions, so the caller must hold those too
+		if !bindRolePermissions(c, append(roleData.Permissions, existingRole.Permissions...)) {
+			return
+		}
+
+		updateFields := bson.M{"updated_at": time.Now()}
+		if roleData.Description != nil {
+			updateFields["description"] = *roleData.Description
+		}
+		if roleData.Permissions != nil {
+			updateFields["permissions"] = roleData.Permissions
+		}
+
+		_, err := RoleCollection.UpdateOne(ctx, bson.M{"_id": roleName}, bson.M{"$set": updateFields})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error updating role"})
+			return
+		}
+		middleware.InvalidateRolePermissions()
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Role updated successfully"})
+	}
+}
+
+func DeleteRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		roleName := c.Param("name")
+
+		var existingRole models.Role
+		if err := RoleCollection.FindOne(ctx, bson.M{"_id": roleName}).Decode(&existingRole); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Role not found"})
+			return
+		}
+		if existingRole.System {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Built-in roles cannot be deleted"})
+			return
+		}
+
+		inUse, err := UserCollection.CountDocuments(ctx, bson.M{"role": roleName})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error checking role usage"})
+			return
+		}
+		if inUse > 0 {
+			c.JSON(http.StatusConflict, gin.H{"success": false, "error": "Role is still assigned to users"})
+			return
+		}
+
+		if _, err := RoleCollection.DeleteOne(ctx, bson.M{"_id": roleName}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error deleting role"})
+			return
+		}
+		middleware.InvalidateRolePermissions()
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Role deleted successfully"})
+	}
+}
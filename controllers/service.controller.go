@@ -28,6 +28,15 @@ func CreateService() gin.HandlerFunc {
 			return
 		}
 
+		status, ok := bindPublishStatus(c, service.Status, service.Publish_At)
+		if !ok {
+			return
+		}
+		service.Status = &status
+		if !validTranslations(c, service.Translations, serviceTranslatable) {
+			return
+		}
+
 		service.Service_ID = primitive.NewObjectID()
 		service.Created_At = time.Now()
 		service.Updated_At = time.Now()
@@ -62,16 +71,30 @@ func UpdateService() gin.HandlerFunc {
 
 		service.Updated_At = time.Now()
 
-		update := bson.M{
-			"$set": bson.M{
-				"title":      service.Title,
-				"content":    service.Content,
-				"image":      service.Image,
-				"t1":         service.T1,
-				"t2":         service.T2,
-				"updated_at": service.Updated_At,
-			},
+		fields := bson.M{
+			"title":      service.Title,
+			"content":    service.Content,
+			"image":      service.Image,
+			"t1":         service.T1,
+			"t2":         service.T2,
+			"updated_at": service.Updated_At,
+		}
+		// Translations left out of the request are kept
+		if service.Translations != nil {
+			if !validTranslations(c, service.Translations, serviceTranslatable) {
+				return
+			}
+			fields["translations"] = service.Translations
+		}
+		if service.Status != nil {
+			status, ok := bindPublishStatus(c, service.Status, service.Publish_At)
+			if !ok {
+				return
+			}
+			fields["status"] = status
+			fields["publish_at"] = service.Publish_At
 		}
+		update := bson.M{"$set": fields}
 
 		result, err := ServiceCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
 		if err != nil {
@@ -121,7 +144,7 @@ func GetAllServices() gin.HandlerFunc {
 		defer cancel()
 
 		var services []models.Service
-		cursor, err := ServiceCollection.Find(ctx, bson.M{})
+		cursor, err := ServiceCollection.Find(ctx, visibleContentFilter(c, bson.M{}))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving services"})
 			return
@@ -132,6 +155,11 @@ func GetAllServices() gin.HandlerFunc {
 			return
 		}
 
+		locale := requestLocale(c)
+		for i := range services {
+			localize(locale, &services[i], &services[i].Translations)
+		}
+
 		c.JSON(http.StatusOK, gin.H{"success": true, "services": services})
 	}
 }
@@ -149,13 +177,19 @@ func GetOneService() gin.HandlerFunc {
 		defer cancel()
 
 		var service models.Service
-		err = ServiceCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&service)
+		err = ServiceCollection.FindOne(ctx, visibleContentFilter(c, bson.M{"_id": objID})).Decode(&service)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Service not found"})
+			return
+		}
 		if err != nil {
 			log.Printf("Error retrieving service: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving service", "details": err.Error()})
 			return
 		}
 
+		localize(requestLocale(c), &service, &service.Translations)
+
 		c.JSON(http.StatusOK, gin.H{"success": true, "service": service})
 	}
 }
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"portfolio/database"
+	"portfolio/middleware"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var SessionCollection *mongo.Collection = database.PortfolioData(database.Client, "Sessions")
+
+// createSession records a new login for the user based on the request's client details
+func createSession(ctx context.Context, c *gin.Context, userID string) (models.Session, error) {
+	session := newSession(c, userID)
+	_, err := SessionCollection.InsertOne(ctx, session)
+	return session, err
+}
+
+// newSession describes a session for the user from the request's client details without storing it
+func newSession(c *gin.Context, userID string) models.Session {
+	userAgent := c.Request.UserAgent()
+	browser, os := middleware.ParseUserAgent(userAgent)
+
+	return models.Session{
+		Session_ID:   primitive.NewObjectID(),
+		User_ID:      userID,
+		Device:       middleware.DeviceType(userAgent),
+		IP:           c.ClientIP(),
+		Browser:      browser,
+		OS:           os,
+		User_Agent:   userAgent,
+		Created_At:   time.Now(),
+		Last_Seen_At: time.Now(),
+	}
+}
+
+// sessionObjectID converts a session ID taken from a token or refresh token record
+func sessionObjectID(sessionID string) primitive.ObjectID {
+	objID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return primitive.NilObjectID
+	}
+	return objID
+}
+
+// touchSession bumps the session's last seen time
+func touchSession(ctx context.Context, sessionID string) {
+	_, err := SessionCollection.UpdateOne(ctx, bson.M{"_id": sessionObjectID(sessionID)}, bson.M{"$set": bson.M{"last_seen_at": time.Now()}})
+	if err != nil {
+		log.Printf("Error updating session: %v", err)
+	}
+}
+
+// revokeSessions revokes the matching sessions along with their refresh tokens
+func revokeSessions(ctx context.Context, filter bson.M) error {
+	filter["revoked"] = false
+
+	cursor, err := SessionCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	sessionIDs := make([]primitive.ObjectID, 0, len(sessions))
+	familyIDs := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		sessionIDs = append(sessionIDs, session.Session_ID)
+		familyIDs = append(familyIDs, session.Session_ID.Hex())
+	}
+
+	now := time.Now()
+	_, err = SessionCollection.UpdateMany(
+		ctx,
+		bson.M{"_id": bson.M{"$in": sessionIDs}},
+		bson.M{"$set": bson.M{"revoked": true, "revoked_at": now}},
+	)
+	if err != nil {
+		return err
+	}
+
+	return revokeTokenFamilies(ctx, familyIDs)
+}
+
+// userSessionsFilter matches the user's active sessions. Sessions an admin opened to impersonate the user are
+// left out; they are the admin's, and are ended from the admin side.
+func userSessionsFilter(userID string) bson.M {
+	return bson.M{"user_id": userID, "revoked": false, "impersonator_id": bson.M{"$exists": false}}
+}
+
+func GetAllSessions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDFromMdw, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Unauthorized"})
+			return
+		}
+
+		userIDStr, ok := userIDFromMdw.(string)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Invalid user ID format"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		opts := options.Find().SetSort(bson.M{"last_seen_at": -1})
+		cursor, err := SessionCollection.Find(ctx, userSessionsFilter(userIDStr), opts)
+		if err != nil {
+			log.Printf("Error finding sessions: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving sessions"})
+			return
+		}
+
+		var sessions []models.Session
+		if err = cursor.All(ctx, &sessions); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error decoding sessions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":         true,
+			"sessions":        sessions,
+			"current_session": c.GetString("sessionId"),
+		})
+	}
+}
+
+func RevokeSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID := c.Param("id")
+		objID, err := primitive.ObjectIDFromHex(sessionID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid session ID"})
+			return
+		}
+
+		userIDFromMdw, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Unauthorized"})
+			return
+		}
+
+		userIDStr, ok := userIDFromMdw.(string)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Invalid user ID format"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var session models.Session
+		err = SessionCollection.FindOne(ctx, bson.M{"_id": objID, "user_id": userIDStr}).Decode(&session)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Session not found or access denied"})
+			return
+		}
+
+		if err := revokeSessions(ctx, bson.M{"_id": objID}); err != nil {
+			log.Printf("Error revoking session: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error revoking session"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Session revoked successfully"})
+	}
+}
+
+func RevokeAllSessions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDFromMdw, exists := c.Get("userId")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Unauthorized"})
+			return
+		}
+
+		userIDStr, ok := userIDFromMdw.(string)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Invalid user ID format"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{"user_id": userIDStr}
+		if c.Query("keep_current") == "true" {
+			filter["_id"] = bson.M{"$ne": sessionObjectID(c.GetString("sessionId"))}
+		}
+
+		if err := revokeSessions(ctx, filter); err != nil {
+			log.Printf("Error revoking sessions: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error revoking sessions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Sessions revoked successfully"})
+	}
+}
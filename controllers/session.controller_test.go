@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUserSessionsFilterHidesImpersonation(t *testing.T) {
+	want := bson.M{"user_id": "user-1", "revoked": false, "impersonator_id": bson.M{"$exists": false}}
+	if got := userSessionsFilter("user-1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("userSessionsFilter() = %v, want %v", got, want)
+	}
+}
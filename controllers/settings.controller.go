@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"portfolio/database"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var SettingsCollection *mongo.Collection = database.PortfolioData(database.Client, "Settings")
+
+const expenseSettingsID = "expense"
+
+// getSettings loads the expense app settings, falling back to defaults when none are stored yet
+func getSettings(ctx context.Context) (models.Settings, error) {
+	settings := models.Settings{Settings_ID: expenseSettingsID}
+
+	err := SettingsCollection.FindOne(ctx, bson.M{"_id": expenseSettingsID}).Decode(&settings)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return settings, err
+	}
+	if settings.Registration_Mode == "" {
+		settings.Registration_Mode = models.RegistrationOpen
+	}
+	return settings, nil
+}
+
+// GetRegistrationMode tells the sign up page whether registration is open, invite only or closed
+func GetRegistrationMode() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		settings, err := getSettings(ctx)
+		if err != nil {
+			log.Printf("Error retrieving settings: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving settings"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "registration_mode": settings.Registration_Mode})
+	}
+}
+
+func GetSettings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		settings, err := getSettings(ctx)
+		if err != nil {
+			log.Printf("Error retrieving settings: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving settings"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "settings": settings})
+	}
+}
+
+func UpdateSettings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var settingsData struct {
+			RequireEmailVerification *bool   `json:"require_email_verification"`
+			RegistrationMode         *string `json:"registration_mode"`
+		}
+		if err := c.BindJSON(&settingsData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		updateFields := bson.M{"updated_at": time.Now()}
+		if settingsData.RequireEmailVerification != nil {
+			updateFields["require_email_verification"] = *settingsData.RequireEmailVerification
+		}
+		if settingsData.RegistrationMode != nil {
+			if !models.IsValidRegistrationMode(*settingsData.RegistrationMode) {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Registration mode must be open, invite_only or closed"})
+				return
+			}
+			updateFields["registration_mode"] = *settingsData.RegistrationMode
+		}
+
+		_, err := SettingsCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": expenseSettingsID},
+			bson.M{"$set": updateFields},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			log.Printf("Error updating settings: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error updating settings"})
+			return
+		}
+
+		settings, err := getSettings(ctx)
+		if err != nil {
+			log.Printf("Error retrieving settings: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving settings"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Settings updated successfully", "settings": settings})
+	}
+}
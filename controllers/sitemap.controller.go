@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"portfolio/helpers"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Frontend paths of portfolio content
+func postPath(slug string) string                  { return "/blog/" + slug }
+func projectPath(id primitive.ObjectID) string     { return "/projects/" + id.Hex() }
+func servicePath(id primitive.ObjectID) string     { return "/services/" + id.Hex() }
+func certificatePath(id primitive.ObjectID) string { return "/certificates/" + id.Hex() }
+
+// resumePath is the page showing experience, education and skills together; the entries have no pages of their own
+const resumePath = "/resume"
+
+// sitemapDocument is the part of a content document a sitemap needs
+type sitemapDocument struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Slug       string             `bson:"slug"`
+	Updated_At time.Time          `bson:"updated_at"`
+}
+
+// sitemapSource is a collection of public pages
+type sitemapSource struct {
+	collection *mongo.Collection
+	filter     func() bson.M
+	path       func(sitemapDocument) string
+}
+
+func sitemapSources() []sitemapSource {
+	return []sitemapSource{
+		{PostCollection, publishedPostFilter, func(doc sitemapDocument) string { return postPath(doc.Slug) }},
+		{ProjectCollection, publishedContentFilter, func(doc sitemapDocument) string { return projectPath(doc.ID) }},
+		{ServiceCollection, publishedContentFilter, func(doc sitemapDocument) string { return servicePath(doc.ID) }},
+		{CertificateCollection, publishedContentFilter, func(doc sitemapDocument) string { return certificatePath(doc.ID) }},
+	}
+}
+
+// lastUpdated finds when a document matching the filter last changed, or the zero time when none match
+func lastUpdated(ctx context.Context, collection *mongo.Collection, filter bson.M) (time.Time, error) {
+	var latest sitemapDocument
+	err := collection.FindOne(ctx, filter,
+		options.FindOne().SetSort(bson.D{{Key: "updated_at", Value: -1}}).SetProjection(bson.M{"updated_at": 1}),
+	).Decode(&latest)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return time.Time{}, err
+	}
+	return latest.Updated_At, nil
+}
+
+// sitemapPages lists the pages not generated one per document, the home page first. The resume page changes
+// whenever one of its published entries does.
+func sitemapPages(ctx context.Context) ([]helpers.SitemapURL, error) {
+	var resumeModified time.Time
+	for _, collection := range []*mongo.Collection{ExperienceCollection, EducationCollection, SkillCollection} {
+		updated, err := lastUpdated(ctx, collection, publishedContentFilter())
+		if err != nil {
+			return nil, err
+		}
+		if updated.After(resumeModified) {
+			resumeModified = updated
+		}
+	}
+
+	return []helpers.SitemapURL{
+		{Loc: portfolioURL() + "/"},
+		{Loc: portfolioURL() + resumePath, LastMod: resumeModified},
+	}, nil
+}
+
+// sitemapSummary counts the public pages, including the home and resume pages, and finds when any last changed
+func sitemapSummary(ctx context.Context) (int64, time.Time, error) {
+	var lastModified time.Time
+
+	pages, err := sitemapPages(ctx)
+	if err != nil {
+		return 0, lastModified, err
+	}
+	total := int64(len(pages))
+	for _, page := range pages {
+		if page.LastMod.After(lastModified) {
+			lastModified = page.LastMod
+		}
+	}
+
+	for _, source := range sitemapSources() {
+		count, err := source.collection.CountDocuments(ctx, source.filter())
+		if err != nil {
+			return 0, lastModified, err
+		}
+		total += count
+
+		updated, err := lastUpdated(ctx, source.collection, source.filter())
+		if err != nil {
+			return 0, lastModified, err
+		}
+		if updated.After(lastModified) {
+			lastModified = updated
+		}
+	}
+	return total, lastModified, nil
+}
+
+// sitemapURLs lists up to limit public pages starting at offset, the home and resume pages first and then
+// each collection in turn
+func sitemapURLs(ctx context.Context, offset int64, limit int64) ([]helpers.SitemapURL, error) {
+	pages, err := sitemapPages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []helpers.SitemapURL
+	if offset < int64(len(pages)) {
+		pages = pages[offset:]
+		if int64(len(pages)) > limit {
+			pages = pages[:limit]
+		}
+		urls = append(urls, pages...)
+		limit -= int64(len(pages))
+		offset = 0
+	} else {
+		offset -= int64(len(pages))
+	}
+
+	for _, source := range sitemapSources() {
+		if limit <= 0 {
+			break
+		}
+
+		count, err := source.collection.CountDocuments(ctx, source.filter())
+		if err != nil {
+			return nil, err
+		}
+		if offset >= count {
+			offset -= count
+			continue
+		}
+
+		opts := options.Find().
+			SetSort(bson.D{{Key: "_id", Value: 1}}).
+			SetSkip(offset).
+			SetLimit(limit).
+			SetProjection(bson.M{"slug": 1, "updated_at": 1})
+		cursor, err := source.collection.Find(ctx, source.filter(), opts)
+		if err != nil {
+			return nil, err
+		}
+		var docs []sitemapDocument
+		if err := cursor.All(ctx, &docs); err != nil {
+			return nil, err
+		}
+
+		for _, doc := range docs {
+			urls = append(urls, helpers.SitemapURL{Loc: portfolioURL() + source.path(doc), LastMod: doc.Updated_At})
+		}
+		offset = 0
+		limit -= int64(len(docs))
+	}
+	return urls, nil
+}
+
+// sitemapPage writes the sitemap of the pages starting at offset
+func sitemapPage(ctx context.Context, offset int64) ([]byte, error) {
+	urls, err := sitemapURLs(ctx, offset, helpers.SitemapMaxURLs)
+	if err != nil {
+		return nil, err
+	}
+	return helpers.Sitemap(urls)
+}
+
+// GetSitemap serves the sitemap, or a sitemap index once there are more pages than one sitemap may list.
+// Crawlers only accept a sitemap listing pages on its own host, so every URL is under PORTFOLIO_URL and the
+// frontend must serve /sitemap.xml, /sitemaps/* and /robots.txt by proxying them to this API.
+func GetSitemap() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		total, lastModified, err := sitemapSummary(ctx)
+		if err != nil {
+			log.Printf("Error building sitemap: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error building sitemap"})
+			return
+		}
+
+		var body []byte
+		if total <= helpers.SitemapMaxURLs {
+			body, err = sitemapPage(ctx, 0)
+		} else {
+			var sitemaps []helpers.SitemapURL
+			for page := int64(1); (page-1)*helpers.SitemapMaxURLs < total; page++ {
+				sitemaps = append(sitemaps, helpers.SitemapURL{
+					Loc:     portfolioURL() + "/sitemaps/" + strconv.FormatInt(page, 10) + ".xml",
+					LastMod: lastModified,
+				})
+			}
+			body, err = helpers.SitemapIndex(sitemaps)
+		}
+		if err != nil {
+			log.Printf("Error building sitemap: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error building sitemap"})
+			return
+		}
+
+		serveWithValidators(c, "application/xml; charset=utf-8", body, lastModified)
+	}
+}
+
+// GetSitemapPage serves one of the sitemaps listed by the sitemap index
+func GetSitemapPage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := strconv.ParseInt(strings.TrimSuffix(c.Param("page"), ".xml"), 10, 64)
+		if err != nil || page < 1 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Sitemap not found"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		total, lastModified, err := sitemapSummary(ctx)
+		if err != nil {
+			log.Printf("Error building sitemap: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error building sitemap"})
+			return
+		}
+
+		offset := (page - 1) * helpers.SitemapMaxURLs
+		if offset >= total {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Sitemap not found"})
+			return
+		}
+
+		body, err := sitemapPage(ctx, offset)
+		if err != nil {
+			log.Printf("Error building sitemap: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error building sitemap"})
+			return
+		}
+
+		serveWithValidators(c, "application/xml; charset=utf-8", body, lastModified)
+	}
+}
+
+// GetRobotsTxt lets crawlers in and points them at the sitemap on the frontend's host
+func GetRobotsTxt() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		robots := "User-agent: *\nAllow: /\n\nSitemap: " + portfolioURL() + "/sitemap.xml\n"
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(robots))
+	}
+}
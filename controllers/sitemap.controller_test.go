@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRobotsTxtPointsAtFrontendSitemap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("PORTFOLIO_URL", "https://www.example.com/")
+
+	router := gin.New()
+	router.GET("/robots.txt", GetRobotsTxt())
+
+	req := httptest.NewRequest(http.MethodGet, "/robots.txt", nil)
+	req.Host = "evil.example.net"
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if !strings.Contains(recorder.Body.String(), "Sitemap: https://www.example.com/sitemap.xml\n") {
+		t.Errorf("robots.txt = %q", recorder.Body.String())
+	}
+}
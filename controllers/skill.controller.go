@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"portfolio/database"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var SkillCollection *mongo.Collection = database.PortfolioData(database.Client, "Skills")
+
+// maxSkillProficiency is the top of the proficiency scale
+const maxSkillProficiency = 5
+
+// bindSkill binds and validates a skill from a create or update request
+func bindSkill(c *gin.Context, skill *models.Skill) bool {
+	if err := c.BindJSON(skill); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return false
+	}
+
+	skill.Name = strings.TrimSpace(skill.Name)
+	skill.Category = strings.TrimSpace(skill.Category)
+	if skill.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Name is required"})
+		return false
+	}
+	if skill.Proficiency < 0 || skill.Proficiency > maxSkillProficiency {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Proficiency must be between 1 and 5, or 0 to leave it unrated"})
+		return false
+	}
+	if skill.Years < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Years cannot be negative"})
+		return false
+	}
+	return validTranslations(c, skill.Translations, skillTranslatable)
+}
+
+func CreateSkill() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var skill models.Skill
+		if !bindSkill(c, &skill) {
+			return
+		}
+
+		status, ok := bindPublishStatus(c, skill.Status, skill.Publish_At)
+		if !ok {
+			return
+		}
+		skill.Status = &status
+
+		skill.Skill_ID = primitive.NewObjectID()
+		skill.Created_At = time.Now()
+		skill.Updated_At = time.Now()
+
+		_, err := SkillCollection.InsertOne(ctx, skill)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating skill"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Skill created successfully!", "skill": skill})
+	}
+}
+
+func UpdateSkill() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		skillID := c.Param("id")
+		objID, err := primitive.ObjectIDFromHex(skillID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid skill ID"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var skill models.Skill
+		if !bindSkill(c, &skill) {
+			return
+		}
+		skill.Updated_At = time.Now()
+
+		fields := bson.M{
+			"name":        skill.Name,
+			"category":    skill.Category,
+			"proficiency": skill.Proficiency,
+			"years":       skill.Years,
+			"sort_order":  skill.Sort_Order,
+			"updated_at":  skill.Updated_At,
+		}
+		// Translations left out of the request are kept
+		if skill.Translations != nil {
+			fields["translations"] = skill.Translations
+		}
+		if skill.Status != nil {
+			status, ok := bindPublishStatus(c, skill.Status, skill.Publish_At)
+			if !ok {
+				return
+			}
+			fields["status"] = status
+			fields["publish_at"] = skill.Publish_At
+		}
+		update := bson.M{"$set": fields}
+
+		result, err := SkillCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error updating skill", "details": err.Error()})
+			return
+		}
+
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Skill not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Skill updated successfully"})
+	}
+}
+
+func DeleteSkill() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		skillID := c.Param("id")
+		objID, err := primitive.ObjectIDFromHex(skillID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid skill ID"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		result, err := SkillCollection.DeleteOne(ctx, bson.M{"_id": objID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error deleting skill"})
+			return
+		}
+
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Skill not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Skill deleted successfully"})
+	}
+}
+
+// GetAllSkills lists skills in display order, optionally narrowed to a category
+func GetAllSkills() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		filter := bson.M{}
+		if category := c.Query("category"); category != "" {
+			filter["category"] = category
+		}
+
+		skills, err := findSkills(ctx, c, filter)
+		if err != nil {
+			log.Printf("Error retrieving skills: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving skills"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "skills": skills})
+	}
+}
+
+func GetOneSkill() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		skillID := c.Param("id")
+		objID, err := primitive.ObjectIDFromHex(skillID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid skill ID"})
+			return
+		}
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var skill models.Skill
+		err = SkillCollection.FindOne(ctx, visibleContentFilter(c, bson.M{"_id": objID})).Decode(&skill)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "Skill not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("Error retrieving skill: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving skill"})
+			return
+		}
+
+		localize(requestLocale(c), &skill, &skill.Translations)
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "skill": skill})
+	}
+}
@@ -0,0 +1,322 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// statsCacheTTL reads STATS_CACHE_TTL (e.g. "5m"), defaulting to five minutes; "0" disables caching
+func statsCacheTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("STATS_CACHE_TTL")); err == nil && ttl >= 0 {
+		return ttl
+	}
+	return 5 * time.Minute
+}
+
+type cachedStats struct {
+	value    interface{}
+	loadedAt time.Time
+}
+
+var (
+	statsCacheMutex sync.Mutex
+	statsCache      = map[string]cachedStats{}
+)
+
+// cachedStat returns the cached result for key, computing and storing it when missing or stale
+func cachedStat(ctx context.Context, key string, compute func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	ttl := statsCacheTTL()
+
+	statsCacheMutex.Lock()
+	cached, ok := statsCache[key]
+	statsCacheMutex.Unlock()
+	if ok && time.Since(cached.loadedAt) < ttl {
+		return cached.value, nil
+	}
+
+	value, err := compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statsCacheMutex.Lock()
+	statsCache[key] = cachedStats{value: value, loadedAt: time.Now()}
+	statsCacheMutex.Unlock()
+	return value, nil
+}
+
+// statsDays reads the days query parameter, defaulting to 30 and capped at a year
+func statsDays(c *gin.Context) int {
+	days, err := strconv.Atoi(c.Query("days"))
+	if err != nil || days < 1 {
+		return 30
+	}
+	if days > 365 {
+		return 365
+	}
+	return days
+}
+
+// respondWithStat serves a cached statistic under the given response field
+func respondWithStat(c *gin.Context, key string, field string, compute func(ctx context.Context) (interface{}, error)) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	value, err := cachedStat(ctx, key, compute)
+	if err != nil {
+		log.Printf("Error computing %s statistics: %v", field, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error computing statistics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, field: value})
+}
+
+type dailyCount struct {
+	Date  string `json:"date" bson:"_id"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+// countPerDay groups the documents matching filter by the UTC day of dateField. With distinctField set,
+// each value is counted once per day.
+func countPerDay(ctx context.Context, collection *mongo.Collection, filter bson.M, dateField string, distinctField string) ([]dailyCount, error) {
+	cursor, err := collection.Aggregate(ctx, countPerDayPipeline(filter, dateField, distinctField))
+	if err != nil {
+		return nil, err
+	}
+
+	counts := []dailyCount{}
+	err = cursor.All(ctx, &counts)
+	return counts, err
+}
+
+func countPerDayPipeline(filter bson.M, dateField string, distinctField string) mongo.Pipeline {
+	day := bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$" + dateField}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+	}
+	if distinctField != "" {
+		pipeline = append(pipeline,
+			bson.D{{Key: "$group", Value: bson.M{"_id": bson.M{"day": day, "value": "$" + distinctField}}}},
+			bson.D{{Key: "$group", Value: bson.M{"_id": "$_id.day", "count": bson.M{"$sum": 1}}}},
+		)
+	} else {
+		pipeline = append(pipeline, bson.D{{Key: "$group", Value: bson.M{"_id": day, "count": bson.M{"$sum": 1}}}})
+	}
+	return append(pipeline, bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}})
+}
+
+func startOfDayUTC(now time.Time, daysAgo int) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -daysAgo)
+}
+
+// activeUserFilter matches expense app user sessions with dateField at or after since. Portfolio admin logins
+// and sessions an admin opened to impersonate a user are not user activity.
+func activeUserFilter(dateField string, since time.Time, adminIDs []string) bson.M {
+	if adminIDs == nil {
+		adminIDs = []string{}
+	}
+	return bson.M{
+		dateField:         bson.M{"$gte": since},
+		"user_id":         bson.M{"$nin": adminIDs},
+		"impersonator_id": bson.M{"$exists": false},
+	}
+}
+
+// activeUserFilters returns the session filters behind the active user statistics as of now: the daily,
+// weekly and monthly activity windows and sign-ins over the requested number of days
+func activeUserFilters(now time.Time, days int, adminIDs []string) (daily, weekly, monthly, logins bson.M) {
+	return activeUserFilter("last_seen_at", now.Add(-24*time.Hour), adminIDs),
+		activeUserFilter("last_seen_at", now.AddDate(0, 0, -7), adminIDs),
+		activeUserFilter("last_seen_at", now.AddDate(0, 0, -30), adminIDs),
+		activeUserFilter("created_at", startOfDayUTC(now, days-1), adminIDs)
+}
+
+// portfolioAdminIDs lists the IDs sessions of portfolio admins are stored under
+func portfolioAdminIDs(ctx context.Context) ([]string, error) {
+	values, err := UserCollection.Distinct(ctx, "_id", bson.M{"role": models.RolePortfolioAdmin})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			ids = append(ids, id.Hex())
+		}
+	}
+	return ids, nil
+}
+
+// GetRegistrationStats returns new registrations per day
+func GetRegistrationStats() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		days := statsDays(c)
+		respondWithStat(c, "registrations:"+strconv.Itoa(days), "registrations", func(ctx context.Context) (interface{}, error) {
+			since := startOfDayUTC(time.Now(), days-1)
+			return countPerDay(ctx, UserCollection, bson.M{"created_at": bson.M{"$gte": since}}, "created_at", "")
+		})
+	}
+}
+
+// GetActiveUserStats returns daily, weekly and monthly active users, counted as users with session activity
+// in the period, along with the number of distinct users signing in each day. Portfolio admins and
+// impersonation sessions are left out.
+func GetActiveUserStats() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		days := statsDays(c)
+		respondWithStat(c, "active-users:"+strconv.Itoa(days), "activeUsers", func(ctx context.Context) (interface{}, error) {
+			adminIDs, err := portfolioAdminIDs(ctx)
+			if err != nil {
+				return nil, err
+			}
+			dailyFilter, weeklyFilter, monthlyFilter, loginsFilter := activeUserFilters(time.Now(), days, adminIDs)
+
+			activeUsers := func(filter bson.M) (int, error) {
+				userIDs, err := SessionCollection.Distinct(ctx, "user_id", filter)
+				return len(userIDs), err
+			}
+
+			daily, err := activeUsers(dailyFilter)
+			if err != nil {
+				return nil, err
+			}
+			weekly, err := activeUsers(weeklyFilter)
+			if err != nil {
+				return nil, err
+			}
+			monthly, err := activeUsers(monthlyFilter)
+			if err != nil {
+				return nil, err
+			}
+			logins, err := countPerDay(ctx, SessionCollection, loginsFilter, "created_at", "user_id")
+			if err != nil {
+				return nil, err
+			}
+
+			return gin.H{
+				"daily":       daily,
+				"weekly":      weekly,
+				"monthly":     monthly,
+				"daily_users": logins,
+			}, nil
+		})
+	}
+}
+
+// GetContentStats returns user, category and item counts, with items broken down by type
+func GetContentStats() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		respondWithStat(c, "content", "content", func(ctx context.Context) (interface{}, error) {
+			users, err := UserCollection.CountDocuments(ctx, bson.M{})
+			if err != nil {
+				return nil, err
+			}
+			categories, err := ExpenseCategoryCollection.CountDocuments(ctx, bson.M{})
+			if err != nil {
+				return nil, err
+			}
+
+			cursor, err := ExpenseItemCollection.Aggregate(ctx, mongo.Pipeline{
+				{{Key: "$group", Value: bson.M{
+					"_id":   "$type",
+					"count": bson.M{"$sum": 1},
+					"total": bson.M{"$sum": "$amount"},
+				}}},
+				{{Key: "$sort", Value: bson.M{"_id": 1}}},
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			itemsByType := []struct {
+				Type  string  `json:"type" bson:"_id"`
+				Count int64   `json:"count" bson:"count"`
+				Total float64 `json:"total" bson:"total"`
+			}{}
+			if err := cursor.All(ctx, &itemsByType); err != nil {
+				return nil, err
+			}
+
+			var items int64
+			for _, itemType := range itemsByType {
+				items += itemType.Count
+			}
+
+			return gin.H{
+				"users":         users,
+				"categories":    categories,
+				"items":         items,
+				"items_by_type": itemsByType,
+			}, nil
+		})
+	}
+}
+
+// GetTopCategoryStats ranks category titles across all users by the total amount of their items
+func GetTopCategoryStats() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil || limit < 1 || limit > 50 {
+			limit = 10
+		}
+
+		respondWithStat(c, "top-categories:"+strconv.Itoa(limit), "topCategories", func(ctx context.Context) (interface{}, error) {
+			cursor, err := ExpenseItemCollection.Aggregate(ctx, mongo.Pipeline{
+				{{Key: "$group", Value: bson.M{
+					"_id":   "$category_id",
+					"count": bson.M{"$sum": 1},
+					"total": bson.M{"$sum": "$amount"},
+				}}},
+				// Items store their category ID as a string
+				{{Key: "$lookup", Value: bson.M{
+					"from": ExpenseCategoryCollection.Name(),
+					"let":  bson.M{"categoryId": bson.M{"$convert": bson.M{"input": "$_id", "to": "objectId", "onError": nil, "onNull": nil}}},
+					"pipeline": bson.A{
+						bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$_id", "$$categoryId"}}}},
+						bson.M{"$project": bson.M{"title": 1, "type": 1}},
+					},
+					"as": "category",
+				}}},
+				{{Key: "$unwind", Value: "$category"}},
+				// Users name their own categories, so equal titles are counted together
+				{{Key: "$group", Value: bson.M{
+					"_id":        bson.M{"title": bson.M{"$toLower": "$category.title"}, "type": "$category.type"},
+					"title":      bson.M{"$first": "$category.title"},
+					"categories": bson.M{"$sum": 1},
+					"count":      bson.M{"$sum": "$count"},
+					"total":      bson.M{"$sum": "$total"},
+				}}},
+				{{Key: "$sort", Value: bson.D{{Key: "total", Value: -1}, {Key: "count", Value: -1}}}},
+				{{Key: "$limit", Value: limit}},
+				{{Key: "$project", Value: bson.M{"_id": 0, "title": 1, "type": "$_id.type", "categories": 1, "count": 1, "total": 1}}},
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			topCategories := []struct {
+				Title      string  `json:"title" bson:"title"`
+				Type       string  `json:"type" bson:"type"`
+				Categories int64   `json:"categories" bson:"categories"`
+				Count      int64   `json:"count" bson:"count"`
+				Total      float64 `json:"total" bson:"total"`
+			}{}
+			err = cursor.All(ctx, &topCategories)
+			return topCategories, err
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestActiveUserFilters(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 15, 30, 0, 0, time.UTC)
+	adminIDs := []string{"admin-1"}
+
+	daily, weekly, monthly, logins := activeUserFilters(now, 7, adminIDs)
+
+	excluded := func(dateField string, since time.Time) bson.M {
+		return bson.M{
+			dateField:         bson.M{"$gte": since},
+			"user_id":         bson.M{"$nin": []string{"admin-1"}},
+			"impersonator_id": bson.M{"$exists": false},
+		}
+	}
+	tests := []struct {
+		name string
+		got  bson.M
+		want bson.M
+	}{
+		{"daily", daily, excluded("last_seen_at", time.Date(2024, time.March, 9, 15, 30, 0, 0, time.UTC))},
+		{"weekly", weekly, excluded("last_seen_at", time.Date(2024, time.March, 3, 15, 30, 0, 0, time.UTC))},
+		{"monthly", monthly, excluded("last_seen_at", time.Date(2024, time.February, 9, 15, 30, 0, 0, time.UTC))},
+		{"logins", logins, excluded("created_at", time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC))},
+	}
+
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s filter = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestActiveUserFilterWithoutAdminsStillExcludesImpersonation(t *testing.T) {
+	filter := activeUserFilter("last_seen_at", time.Now(), nil)
+
+	// A nil slice would be sent as null, which $nin rejects
+	if nin := filter["user_id"].(bson.M)["$nin"].([]string); nin == nil {
+		t.Error("$nin is nil, want an empty list")
+	}
+	if filter["impersonator_id"] == nil {
+		t.Error("impersonation sessions are not excluded")
+	}
+}
+
+func TestCountPerDayPipelineCountsDistinctUsers(t *testing.T) {
+	filter := bson.M{"created_at": bson.M{"$gte": time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)}}
+	day := bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$created_at"}}
+
+	want := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"day": day, "value": "$user_id"}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$_id.day", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	if got := countPerDayPipeline(filter, "created_at", "user_id"); !reflect.DeepEqual(got, want) {
+		t.Errorf("countPerDayPipeline() = %v, want %v", got, want)
+	}
+}
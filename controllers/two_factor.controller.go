@@ -0,0 +1,404 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"portfolio/database"
+	"portfolio/helpers"
+	"portfolio/models"
+	token "portfolio/tokens"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UsedChallengeCollection remembers the two-factor challenge tokens that completed a login until they expire
+var UsedChallengeCollection *mongo.Collection = database.PortfolioData(database.Client, "UsedChallenges")
+
+const recoveryCodeCount = 10
+
+// twoFactorClock is the time authentication codes are checked against
+var twoFactorClock = time.Now
+
+// EnsureTwoFactorIndexes expires used challenge tokens once the tokens themselves would have expired
+func EnsureTwoFactorIndexes(ctx context.Context) error {
+	_, err := UsedChallengeCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// consumeChallengeToken marks a challenge token as used, reporting false if it already completed a login
+func consumeChallengeToken(ctx context.Context, claims *token.SignedDetails) (bool, error) {
+	_, err := UsedChallengeCollection.InsertOne(ctx, bson.M{"_id": claims.ID, "expires_at": claims.ExpiresAt.Time})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// totpIssuer is the account issuer shown in authenticator apps
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "Expenzo"
+}
+
+// hashRecoveryCodes hashes recovery codes for storage
+func hashRecoveryCodes(codes []string) []string {
+	hashed := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashed = append(hashed, helpers.HashToken(helpers.NormalizeRecoveryCode(code)))
+	}
+	return hashed
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused recovery code.
+// TOTP steps and recovery codes are consumed atomically so neither can be replayed.
+func verifySecondFactor(ctx context.Context, user models.User, code string) (bool, error) {
+	if step, ok := helpers.ValidateTOTPAfter(user.TOTP_Secret, code, twoFactorClock(), user.TOTP_Last_Step); ok {
+		result, err := UserCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": user.User_ID, "totp_last_step": bson.M{"$not": bson.M{"$gte": step}}},
+			bson.M{"$set": bson.M{"totp_last_step": step}},
+		)
+		if err != nil {
+			return false, err
+		}
+		return result.MatchedCount == 1, nil
+	}
+
+	hashedCode := helpers.HashToken(helpers.NormalizeRecoveryCode(code))
+	result, err := UserCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": user.User_ID, "recovery_codes": hashedCode},
+		bson.M{"$pull": bson.M{"recovery_codes": hashedCode}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// completeChallenge uses up the challenge token of a two-step login, responding when it was already used
+func completeChallenge(ctx context.Context, c *gin.Context, claims *token.SignedDetails) bool {
+	consumed, err := consumeChallengeToken(ctx, claims)
+	if err != nil {
+		log.Printf("Error consuming challenge token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+		return false
+	}
+	if !consumed {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid or expired challenge token"})
+		return false
+	}
+	return true
+}
+
+// currentUser loads the user identified by the authentication middleware
+func currentUser(ctx context.Context, c *gin.Context) (models.User, bool) {
+	var user models.User
+
+	userIDFromMdw, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Unauthorized"})
+		return user, false
+	}
+
+	userIDStr, ok := userIDFromMdw.(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Invalid user ID format"})
+		return user, false
+	}
+
+	objID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid user ID"})
+		return user, false
+	}
+
+	if err := UserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "User not found"})
+		return user, false
+	}
+	return user, true
+}
+
+func VerifyLoginTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var twoFactorData struct {
+			ChallengeToken string `json:"challenge_token" binding:"required"`
+			Code           string `json:"code" binding:"required"`
+		}
+		if err := c.BindJSON(&twoFactorData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		claims, msg := token.ValidateChallengeToken(twoFactorData.ChallengeToken)
+		if msg != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid or expired challenge token"})
+			return
+		}
+
+		objID, err := primitive.ObjectIDFromHex(claims.User_ID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid or expired challenge token"})
+			return
+		}
+
+		var user models.User
+		if err := UserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil || !user.TOTP_Enabled {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid or expired challenge token"})
+			return
+		}
+
+		if !allowLoginAttempt(ctx, c, user.Email) {
+			return
+		}
+
+		valid, err := verifySecondFactor(ctx, user, twoFactorData.Code)
+		if err != nil {
+			log.Printf("Error verifying second factor: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+			return
+		}
+		if !valid {
+			if err := loginThrottle.RecordFailure(ctx, user.Email, c.ClientIP()); err != nil {
+				log.Printf("Error recording failed login: %v", err)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid authentication code"})
+			return
+		}
+
+		if !completeChallenge(ctx, c, claims) {
+			return
+		}
+		completeUserLogin(ctx, c, user)
+	}
+}
+
+func SetupTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		user, ok := currentUser(ctx, c)
+		if !ok {
+			return
+		}
+
+		if user.TOTP_Enabled {
+			c.JSON(http.StatusConflict, gin.H{"success": false, "error": "Two-factor authentication is already enabled"})
+			return
+		}
+
+		secret, err := helpers.GenerateTOTPSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error generating secret"})
+			return
+		}
+
+		_, err = UserCollection.UpdateOne(ctx, bson.M{"_id": user.User_ID}, bson.M{"$set": bson.M{"totp_pending_secret": secret}})
+		if err != nil {
+			log.Printf("Error saving pending TOTP secret: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error setting up two-factor authentication"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":          true,
+			"message":          "Scan the provisioning URI and confirm with a code to enable two-factor authentication",
+			"secret":           secret,
+			"provisioning_uri": helpers.TOTPProvisioningURI(secret, totpIssuer(), user.Email),
+		})
+	}
+}
+
+func EnableTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var enableData struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := c.BindJSON(&enableData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		user, ok := currentUser(ctx, c)
+		if !ok {
+			return
+		}
+
+		if user.TOTP_Pending_Secret == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Two-factor setup has not been started"})
+			return
+		}
+
+		step, valid := helpers.ValidateTOTP(user.TOTP_Pending_Secret, enableData.Code, twoFactorClock())
+		if !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid authentication code"})
+			return
+		}
+
+		recoveryCodes, err := helpers.GenerateRecoveryCodes(recoveryCodeCount)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error generating recovery codes"})
+			return
+		}
+
+		_, err = UserCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": user.User_ID},
+			bson.M{
+				"$set": bson.M{
+					"totp_enabled":   true,
+					"totp_secret":    user.TOTP_Pending_Secret,
+					"totp_last_step": step,
+					"recovery_codes": hashRecoveryCodes(recoveryCodes),
+					"updated_at":     time.Now(),
+				},
+				"$unset": bson.M{"totp_pending_secret": ""},
+			},
+		)
+		if err != nil {
+			log.Printf("Error enabling two-factor authentication: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error enabling two-factor authentication"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":        true,
+			"message":        "Two-factor authentication enabled. Store these recovery codes somewhere safe",
+			"recovery_codes": recoveryCodes,
+		})
+	}
+}
+
+func DisableTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var disableData struct {
+			Password string `json:"password" binding:"required"`
+			Code     string `json:"code" binding:"required"`
+		}
+		if err := c.BindJSON(&disableData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		user, ok := currentUser(ctx, c)
+		if !ok {
+			return
+		}
+
+		if !user.TOTP_Enabled {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Two-factor authentication is not enabled"})
+			return
+		}
+
+		if !helpers.CheckPassword(user.Password, disableData.Password) {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Password is incorrect"})
+			return
+		}
+
+		valid, err := verifySecondFactor(ctx, user, disableData.Code)
+		if err != nil {
+			log.Printf("Error verifying second factor: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+			return
+		}
+		if !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid authentication code"})
+			return
+		}
+
+		_, err = UserCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": user.User_ID},
+			bson.M{
+				"$set":   bson.M{"totp_enabled": false, "updated_at": time.Now()},
+				"$unset": bson.M{"totp_secret": "", "totp_pending_secret": "", "totp_last_step": "", "recovery_codes": ""},
+			},
+		)
+		if err != nil {
+			log.Printf("Error disabling two-factor authentication: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error disabling two-factor authentication"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Two-factor authentication disabled"})
+	}
+}
+
+func RegenerateRecoveryCodes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var regenerateData struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := c.BindJSON(&regenerateData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		user, ok := currentUser(ctx, c)
+		if !ok {
+			return
+		}
+
+		if !user.TOTP_Enabled {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Two-factor authentication is not enabled"})
+			return
+		}
+
+		valid, err := verifySecondFactor(ctx, user, regenerateData.Code)
+		if err != nil {
+			log.Printf("Error verifying second factor: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+			return
+		}
+		if !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid authentication code"})
+			return
+		}
+
+		recoveryCodes, err := helpers.GenerateRecoveryCodes(recoveryCodeCount)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error generating recovery codes"})
+			return
+		}
+
+		_, err = UserCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": user.User_ID},
+			bson.M{"$set": bson.M{"recovery_codes": hashRecoveryCodes(recoveryCodes), "updated_at": time.Now()}},
+		)
+		if err != nil {
+			log.Printf("Error saving recovery codes: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error saving recovery codes"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "recovery_codes": recoveryCodes})
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"portfolio/database"
 	"portfolio/helpers"
+	"portfolio/middleware"
 	"portfolio/models"
 	token "portfolio/tokens"
 
@@ -25,28 +26,42 @@ func RegisterUser() gin.HandlerFunc {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		var user models.User
-		if err := c.BindJSON(&user); err != nil {
+		var registerData struct {
+			models.User
+			InviteCode string `json:"invite_code"`
+		}
+		if err := c.BindJSON(&registerData); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
 			return
 		}
+		user := registerData.User
 
 		if !govalidator.IsEmail(user.Email) {
 			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid email format!"})
 			return
 		}
-		if len(user.Password) < 6 {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid password!"})
+		if !validateNewPassword(c, user.Password, user.Email) {
+			return
+		}
+
+		// The registration mode is checked first, so a closed or invite only instance neither reveals which
+		// emails have accounts nor hashes passwords for anyone who asks
+		user.User_ID = primitive.NewObjectID()
+		invite, releaseInvite, err := admitRegistration(ctx, registerData.InviteCode, user.User_ID, user.Email)
+		if err != nil {
+			registrationErrorResponse(c, err)
 			return
 		}
 
 		var exist_user models.User
-		err := UserCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&exist_user)
+		err = UserCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&exist_user)
 		if err == nil {
+			releaseInvite()
 			c.JSON(http.StatusConflict, gin.H{"success": false, "error": "User already exists!"})
 			return
 		}
 		if err != mongo.ErrNoDocuments {
+			releaseInvite()
 			log.Printf("Error retrieving user: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving user"})
 			return
@@ -54,22 +69,39 @@ func RegisterUser() gin.HandlerFunc {
 
 		hashedPassword, hashErr := helpers.HashPassword(user.Password)
 		if hashErr != nil {
+			releaseInvite()
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error hashing password"})
 			return
 		}
 		user.Password = hashedPassword
 
-		user.User_ID = primitive.NewObjectID()
+		user.Role = models.RoleUser
+		user.Verified = false
+		user.TOTP_Enabled = false
+		user.Identities = nil
+		user.Suspended = false
+		user.Suspended_At = nil
+		user.Suspension_Reason = ""
+		user.Deletion_Scheduled = nil
 		user.Created_At = time.Now()
 		user.Updated_At = time.Now()
 
+		if invite != nil {
+			user.Role = invite.Role
+		}
+
 		_, err = UserCollection.InsertOne(ctx, user)
 		if err != nil {
+			releaseInvite()
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating user"})
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{"success": true, "message": "User created successfully"})
+		if err := sendVerificationEmail(ctx, user); err != nil {
+			log.Printf("Error sending verification email: %v", err)
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"success": true, "message": "User created successfully. Please check your email to verify your account"})
 	}
 }
 
@@ -88,11 +120,16 @@ func LoginUser() gin.HandlerFunc {
 			return
 		}
 
+		if !allowLoginAttempt(ctx, c, loginData.Email) {
+			return
+		}
+
 		var user models.User
 		err := UserCollection.FindOne(ctx, bson.M{"email": loginData.Email}).Decode(&user)
 		if err != nil {
 			if err == mongo.ErrNoDocuments {
-				c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid email"})
+				checkPasswordForUnknownUser(loginData.Password)
+				rejectLogin(ctx, c, loginData.Email)
 				return
 			}
 			log.Printf("Error finding user: %v", err)
@@ -102,24 +139,111 @@ func LoginUser() gin.HandlerFunc {
 
 		isValidPassword := helpers.CheckPassword(user.Password, loginData.Password)
 		if !isValidPassword {
-			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "Invalid password"})
+			rejectLogin(ctx, c, loginData.Email)
 			return
 		}
 
-		accessToken, err := token.TokenGenerator(user.Email, user.User_ID.Hex(), user.Role)
+		upgradePasswordHash(ctx, user, loginData.Password)
+
+		beginUserLogin(ctx, c, user)
+	}
+}
+
+// validateNewPassword checks a password against the configured policy and responds with the reason it was refused
+func validateNewPassword(c *gin.Context, password string, email string) bool {
+	if err := helpers.PasswordPolicyFromEnv().Validate(password, email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// upgradePasswordHash rehashes a verified password when its stored hash is bcrypt or uses outdated parameters
+func upgradePasswordHash(ctx context.Context, user models.User, password string) {
+	if !helpers.PasswordNeedsRehash(user.Password) {
+		return
+	}
+
+	hashedPassword, err := helpers.HashPassword(password)
+	if err != nil {
+		log.Printf("Error rehashing password: %v", err)
+		return
+	}
+
+	// Matching on the old hash avoids overwriting a password changed in the meantime
+	_, err = UserCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": user.User_ID, "password": user.Password},
+		bson.M{"$set": bson.M{"password": hashedPassword}},
+	)
+	if err != nil {
+		log.Printf("Error updating password hash: %v", err)
+	}
+}
+
+// beginUserLogin runs the checks shared by every way of signing in once the user is identified, then either
+// asks for the second factor or completes the login
+func beginUserLogin(ctx context.Context, c *gin.Context, user models.User) {
+	if user.Suspended {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "Account is suspended"})
+		return
+	}
+
+	settings, err := getSettings(ctx)
+	if err != nil {
+		log.Printf("Error retrieving settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+		return
+	}
+	if settings.Require_Email_Verification && !user.Verified {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "Email address is not verified"})
+		return
+	}
+
+	if user.TOTP_Enabled {
+		challengeToken, err := token.ChallengeTokenGenerator(user.Email, user.User_ID.Hex(), user.Role)
 		if err != nil {
-			log.Printf("Error generating token: %v", err)
+			log.Printf("Error generating challenge token: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error generating token"})
 			return
 		}
-
 		c.JSON(http.StatusOK, gin.H{
-			"success":     true,
-			"message":     "Login successful",
-			"accessToken": accessToken,
-			"user":        user,
+			"success":             true,
+			"message":             "Two-factor authentication required",
+			"two_factor_required": true,
+			"challengeToken":      challengeToken,
 		})
+		return
+	}
+
+	completeUserLogin(ctx, c, user)
+}
+
+// completeUserLogin starts a session for an authenticated expense user and responds with their tokens
+func completeUserLogin(ctx context.Context, c *gin.Context, user models.User) {
+	recordLoginSuccess(ctx, user.Email)
+
+	session, err := createSession(ctx, c, user.User_ID.Hex())
+	if err != nil {
+		log.Printf("Error creating session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating session"})
+		return
 	}
+
+	accessToken, refreshToken, err := issueTokenPair(ctx, ClientExpense, session.Session_ID.Hex(), user.Email, user.User_ID.Hex(), user.Role)
+	if err != nil {
+		log.Printf("Error generating token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error generating token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"message":      "Login successful",
+		"accessToken":  accessToken,
+		"refreshToken": refreshToken,
+		"user":         models.NewUserResponse(user),
+	})
 }
 
 func GetCurrentUser() gin.HandlerFunc {
@@ -156,7 +280,7 @@ func GetCurrentUser() gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
 			"message": "User retrieved successfully",
-			"users":   user,
+			"users":   models.NewUserResponse(user),
 		})
 	}
 }
@@ -194,7 +318,20 @@ func UpdateUserInfo() gin.HandlerFunc {
 			return
 		}
 
-		if updateData.Email != "" {
+		if updateData.Email != "" && !govalidator.IsEmail(updateData.Email) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid email format!"})
+			return
+		}
+
+		var currentUser models.User
+		if err := UserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&currentUser); err != nil {
+			log.Printf("Error retrieving user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving user"})
+			return
+		}
+		emailChanged := updateData.Email != "" && updateData.Email != currentUser.Email
+
+		if emailChanged {
 			var existingUser models.User
 			err := UserCollection.FindOne(ctx, bson.M{"email": updateData.Email}).Decode(&existingUser)
 			if err == nil && existingUser.User_ID != objID {
@@ -212,18 +349,24 @@ func UpdateUserInfo() gin.HandlerFunc {
 		if updateData.Name != "" {
 			updateFields["name"] = updateData.Name
 		}
-		if updateData.Email != "" {
-			updateFields["email"] = updateData.Email
+		// A new email only replaces the current one once it is confirmed, so a mistyped address cannot lock the
+		// user out. Changing back to the current email cancels the pending change.
+		update := bson.M{}
+		if emailChanged {
+			updateFields["pending_email"] = updateData.Email
+		} else if updateData.Email != "" && currentUser.Pending_Email != "" {
+			update["$unset"] = bson.M{"pending_email": ""}
 		}
 		if updateData.Avatar != "" {
 			updateFields["avatar"] = updateData.Avatar
 		}
 		updateFields["updated_at"] = time.Now()
+		update["$set"] = updateFields
 
 		_, err = UserCollection.UpdateOne(
 			ctx,
 			bson.M{"_id": objID},
-			bson.M{"$set": updateFields},
+			update,
 		)
 		if err != nil {
 			log.Printf("Error updating user: %v", err)
@@ -239,10 +382,16 @@ func UpdateUserInfo() gin.HandlerFunc {
 			return
 		}
 
+		if emailChanged {
+			if err := sendVerificationEmail(ctx, updatedUser); err != nil {
+				log.Printf("Error sending verification email: %v", err)
+			}
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
 			"message": "User updated successfully",
-			"user":    updatedUser,
+			"user":    models.NewUserResponse(updatedUser),
 		})
 	}
 }
@@ -291,6 +440,10 @@ func UpdateUserPassword() gin.HandlerFunc {
 			return
 		}
 
+		if !validateNewPassword(c, passwordData.NewPassword, user.Email) {
+			return
+		}
+
 		hashedPassword, err := helpers.HashPassword(passwordData.NewPassword)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error hashing new password"})
@@ -318,7 +471,7 @@ func UpdateUserRole() gin.HandlerFunc {
 
 		var roleData struct {
 			UserID string `json:"user_id" binding:"required"`
-			Role   int    `json:"role" binding:"required"`
+			Role   string `json:"role" binding:"required"`
 		}
 		if err := c.BindJSON(&roleData); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
@@ -331,6 +484,22 @@ func UpdateUserRole() gin.HandlerFunc {
 			return
 		}
 
+		var targetRole models.Role
+		if err := RoleCollection.FindOne(ctx, bson.M{"_id": roleData.Role}).Decode(&targetRole); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid role"})
+			return
+		}
+
+		var user models.User
+		if err := UserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "User not found"})
+			return
+		}
+
+		if !canManageUser(c, user, targetRole.Permissions) {
+			return
+		}
+
 		_, err = UserCollection.UpdateOne(
 			ctx,
 			bson.M{"_id": objID},
@@ -340,47 +509,34 @@ func UpdateUserRole() gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error updating user role"})
 			return
 		}
+		recordAudit(ctx, c, models.AuditUserRoleUpdate, roleData.UserID, "", user.Role+" -> "+roleData.Role)
 
 		c.JSON(http.StatusOK, gin.H{"success": true, "message": "User role updated successfully"})
 	}
 }
 
-func GetAllUsers() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		defer cancel()
-
-		var users []models.User
-		cursor, err := UserCollection.Find(ctx, bson.M{})
-		if err != nil {
-			log.Printf("Error finding users: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error retrieving users"})
-			return
-		}
-		defer cursor.Close(ctx)
-
-		for cursor.Next(ctx) {
-			var user models.User
-			if err := cursor.Decode(&user); err != nil {
-				log.Printf("Error decoding user: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error decoding user"})
-				return
-			}
-			users = append(users, user)
-		}
+// canManageUser checks that the caller holds every permission of the target user's current role
+// and of any role being assigned, so admins cannot act on or create accounts more privileged than themselves
+func canManageUser(c *gin.Context, target models.User, grantedPermissions []string) bool {
+	callerPerms, err := callerPermissions(c)
+	if err != nil {
+		log.Printf("Error retrieving role permissions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error checking permissions"})
+		return false
+	}
 
-		if err := cursor.Err(); err != nil {
-			log.Printf("Cursor error: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error iterating over users"})
-			return
-		}
+	targetPerms, err := middleware.RolePermissions(target.Role)
+	if err != nil {
+		log.Printf("Error retrieving role permissions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error checking permissions"})
+		return false
+	}
 
-		c.JSON(http.StatusOK, gin.H{
-			"success": true,
-			"message": "Users retrieved successfully",
-			"users":   users,
-		})
+	if !canGrant(callerPerms, append(targetPerms, grantedPermissions...)) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "Cannot manage users with more permissions than you have"})
+		return false
 	}
+	return true
 }
 
 func DeleteUser() gin.HandlerFunc {
@@ -395,17 +551,23 @@ func DeleteUser() gin.HandlerFunc {
 			return
 		}
 
-		result, err := UserCollection.DeleteOne(ctx, bson.M{"_id": objID})
-		if err != nil {
-			log.Printf("Error deleting user: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error deleting user"})
+		var user models.User
+		if err := UserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "User not found"})
 			return
 		}
 
-		if result.DeletedCount == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "User not found"})
+		if !canManageUser(c, user, nil) {
+			return
+		}
+
+		if err := purgeUser(ctx, user); err != nil {
+			log.Printf("Error deleting user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error deleting user"})
 			return
 		}
+		// Only the ID is recorded, since purging the user scrubbed their email from the audit log
+		recordAudit(ctx, c, models.AuditUserDelete, userID, "", "")
 
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
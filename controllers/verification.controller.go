@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"context"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"portfolio/helpers"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const verificationTokenTTL = 24 * time.Hour
+
+// verificationResendCooldown reads VERIFICATION_RESEND_COOLDOWN (e.g. "2m"), defaulting to one minute
+func verificationResendCooldown() time.Duration {
+	if cooldown, err := time.ParseDuration(os.Getenv("VERIFICATION_RESEND_COOLDOWN")); err == nil && cooldown > 0 {
+		return cooldown
+	}
+	return time.Minute
+}
+
+// MigrateVerifiedUsers marks accounts created before email verification existed as verified, so requiring
+// verification does not lock them out
+func MigrateVerifiedUsers(ctx context.Context) error {
+	_, err := UserCollection.UpdateMany(ctx, bson.M{"verified": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"verified": true}})
+	return err
+}
+
+// verificationAddress is the address a verification link is sent to: the new address while an email change
+// is pending, otherwise the account's own
+func verificationAddress(user models.User) string {
+	if user.Pending_Email != "" {
+		return user.Pending_Email
+	}
+	return user.Email
+}
+
+// verificationResendDue reports whether the user has an address to confirm and has waited out the cooldown
+// since the last verification email
+func verificationResendDue(user models.User, now time.Time) bool {
+	if user.Verified && user.Pending_Email == "" {
+		return false
+	}
+	return !now.Before(user.Verification_Sent_At.Add(verificationResendCooldown()))
+}
+
+// sendVerificationEmail stores a fresh verification token for the user and emails the link to the address
+// being confirmed
+func sendVerificationEmail(ctx context.Context, user models.User) error {
+	verificationToken, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = UserCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": user.User_ID},
+		bson.M{"$set": bson.M{
+			"verification_token":   helpers.HashToken(verificationToken),
+			"verification_expires": now.Add(verificationTokenTTL),
+			"verification_sent_at": now,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+
+	link := os.Getenv("EXPENSE_APP_URL") + "/verify-email?token=" + url.QueryEscape(verificationToken)
+	return SendEmailTo(verificationAddress(user), "Verify your email address", verificationEmailBody(user.Name, link))
+}
+
+// verificationEmailBody is the HTML of the verification email, with the user's name and link escaped
+func verificationEmailBody(name string, link string) string {
+	return `
+		<h1>Verify your email address</h1>
+		<p>Hi ` + html.EscapeString(name) + `,</p>
+		<p>Please confirm your email address by clicking the link below. The link expires in 24 hours.</p>
+		<p><a href="` + html.EscapeString(link) + `">Verify email</a></p>
+	`
+}
+
+func VerifyEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var verifyData struct {
+			Token string `json:"token" binding:"required"`
+		}
+		if err := c.BindJSON(&verifyData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		var user models.User
+		err := UserCollection.FindOne(ctx, bson.M{
+			"verification_token":   helpers.HashToken(verifyData.Token),
+			"verification_expires": bson.M{"$gt": time.Now()},
+		}).Decode(&user)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "Invalid or expired verification token"})
+				return
+			}
+			log.Printf("Error finding user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+			return
+		}
+
+		verified := bson.M{"verified": true, "updated_at": time.Now()}
+		unset := bson.M{"verification_token": "", "verification_expires": ""}
+
+		// Confirming a pending address makes it the account's email
+		if user.Pending_Email != "" {
+			var existingUser models.User
+			err := UserCollection.FindOne(ctx, bson.M{"email": user.Pending_Email, "_id": bson.M{"$ne": user.User_ID}}).Decode(&existingUser)
+			if err == nil {
+				c.JSON(http.StatusConflict, gin.H{"success": false, "error": "Email is already in use"})
+				return
+			}
+			if err != mongo.ErrNoDocuments {
+				log.Printf("Error checking email: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+				return
+			}
+			verified["email"] = user.Pending_Email
+			unset["pending_email"] = ""
+		}
+
+		_, err = UserCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": user.User_ID},
+			bson.M{"$set": verified, "$unset": unset},
+		)
+		if err != nil {
+			log.Printf("Error verifying user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error verifying email"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Email verified successfully"})
+	}
+}
+
+func ResendVerificationEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var resendData struct {
+			Email string `json:"email" binding:"required"`
+		}
+		if err := c.BindJSON(&resendData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		// Respond the same way whether or not the address has an account, is verified, is waiting out the cooldown
+		// or the email fails to send, so the endpoint cannot be used to discover registered emails
+		genericResponse := gin.H{"success": true, "message": "If the account exists and is unverified, a verification email has been sent"}
+
+		var user models.User
+		err := UserCollection.FindOne(ctx, bson.M{"$or": bson.A{
+			bson.M{"email": resendData.Email},
+			bson.M{"pending_email": resendData.Email},
+		}}).Decode(&user)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusOK, genericResponse)
+				return
+			}
+			log.Printf("Error finding user: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Internal server error"})
+			return
+		}
+
+		if verificationResendDue(user, time.Now()) {
+			if err := sendVerificationEmail(ctx, user); err != nil {
+				log.Printf("Error sending verification email: %v", err)
+			}
+		}
+
+		c.JSON(http.StatusOK, genericResponse)
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"portfolio/models"
+)
+
+func TestVerificationEmailBodyEscapesInput(t *testing.T) {
+	body := verificationEmailBody(`<script>alert("x")</script>`, `https://app.example.com/verify-email?token=a"><img src=x>`)
+
+	if strings.Contains(body, "<script>") || strings.Contains(body, "<img") {
+		t.Fatalf("body contains unescaped markup:\n%s", body)
+	}
+	if !strings.Contains(body, "&lt;script&gt;") {
+		t.Errorf("name was not escaped:\n%s", body)
+	}
+	if !strings.Contains(body, `href="https://app.example.com/verify-email?token=a&#34;&gt;`) {
+		t.Errorf("link was not escaped:\n%s", body)
+	}
+}
+
+func TestVerificationAddress(t *testing.T) {
+	user := models.User{Email: "old@example.com"}
+	if got := verificationAddress(user); got != "old@example.com" {
+		t.Errorf("verificationAddress = %q, want the account email", got)
+	}
+
+	user.Pending_Email = "new@example.com"
+	if got := verificationAddress(user); got != "new@example.com" {
+		t.Errorf("verificationAddress = %q, want the pending email", got)
+	}
+}
+
+func TestVerificationResendDue(t *testing.T) {
+	t.Setenv("VERIFICATION_RESEND_COOLDOWN", "1m")
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		user models.User
+		want bool
+	}{
+		{"unverified, never sent", models.User{}, true},
+		{"unverified, cooldown passed", models.User{Verification_Sent_At: now.Add(-2 * time.Minute)}, true},
+		{"unverified, within cooldown", models.User{Verification_Sent_At: now.Add(-30 * time.Second)}, false},
+		{"verified", models.User{Verified: true}, false},
+		{"verified with a pending email", models.User{Verified: true, Pending_Email: "new@example.com"}, true},
+		{"pending email within cooldown", models.User{Verified: true, Pending_Email: "new@example.com", Verification_Sent_At: now}, false},
+	}
+
+	for _, tt := range tests {
+		if got := verificationResendDue(tt.user, now); got != tt.want {
+			t.Errorf("%s: verificationResendDue = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
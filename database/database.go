@@ -2,7 +2,9 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"time"
@@ -12,38 +14,43 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// DBSet creates the client without waiting for the database, which it connects to in the background on first
+// use. Ping checks the connection at startup; until then packages can set up their collections, and be
+// tested, without a database.
 func DBSet() *mongo.Client {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatalf("Error loading .env file")
+	// A missing .env file is reported by the commands, which also run with the variables set directly
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("Error loading .env file: %v", err)
 	}
 
-	uri := os.Getenv("MONGODB_URI")
-	if uri == "" {
-		log.Fatal("MONGODB_URI environment variable not set")
+	opts := options.Client()
+	if uri := os.Getenv("MONGODB_URI"); uri != "" {
+		opts.ApplyURI(uri)
 	}
-	client, err := mongo.NewClient(options.Client().ApplyURI(uri))
+	client, err := mongo.Connect(context.Background(), opts)
 	if err != nil {
 		log.Fatal(err)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	err = client.Connect(ctx)
-	if err != nil {
-		log.Fatal(err)
+	return client
+}
+
+var Client *mongo.Client = DBSet()
+
+// Ping checks that MONGODB_URI is set and the database answers
+func Ping() error {
+	if os.Getenv("MONGODB_URI") == "" {
+		return errors.New("MONGODB_URI environment variable not set")
 	}
 
-	err = client.Ping(context.TODO(), nil)
-	if err != nil {
-		log.Println("failed to connect to mongodb")
-		return nil
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := Client.Ping(ctx, nil); err != nil {
+		return fmt.Errorf("failed to connect to mongodb: %w", err)
 	}
 	fmt.Println("Successfully Connected to the mongodb")
-	return client
+	return nil
 }
 
-var Client *mongo.Client = DBSet()
-
 func PortfolioData(client *mongo.Client, CollectionName string) *mongo.Collection {
 	return client.Database("Portfolio").Collection(CollectionName)
 }
@@ -0,0 +1,147 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// exifSegment builds an APP1 segment whose first IFD holds just the orientation tag
+func exifSegment(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], 0x0112)
+	order.PutUint16(tiff[12:], 3)
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// jpegWithSegment encodes a w×h JPEG and inserts the segment right after its start of image marker
+func jpegWithSegment(t *testing.T, w, h int, segment []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	for orientation := uint16(1); orientation <= 8; orientation++ {
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			data := jpegWithSegment(t, 4, 2, exifSegment(order, orientation))
+			if got := jpegOrientation(data); got != int(orientation) {
+				t.Errorf("%v orientation %d read as %d", order, orientation, got)
+			}
+		}
+	}
+}
+
+func TestJPEGOrientationMalformed(t *testing.T) {
+	valid := exifSegment(binary.BigEndian, 6)
+
+	badOrder := append([]byte{}, valid...)
+	copy(badOrder[10:], "XX")
+
+	outOfRange := exifSegment(binary.BigEndian, 9)
+
+	badOffset := append([]byte{}, valid...)
+	binary.BigEndian.PutUint32(badOffset[14:], 0xFFFFFFF0)
+
+	tooManyEntries := append([]byte{}, valid...)
+	binary.BigEndian.PutUint16(tooManyEntries[18:], 0xFFFF)
+	binary.BigEndian.PutUint16(tooManyEntries[20:], 0x0100)
+
+	tests := map[string][]byte{
+		"empty":            nil,
+		"not a jpeg":       []byte("\x89PNG\r\n\x1a\n"),
+		"no exif":          jpegWithSegment(t, 4, 2, nil),
+		"bad byte order":   jpegWithSegment(t, 4, 2, badOrder),
+		"out of range":     jpegWithSegment(t, 4, 2, outOfRange),
+		"bad ifd offset":   jpegWithSegment(t, 4, 2, badOffset),
+		"too many entries": jpegWithSegment(t, 4, 2, tooManyEntries),
+		"truncated":        append([]byte{0xFF, 0xD8}, valid[:12]...),
+		"segment too long": {0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF, 'E', 'x'},
+		"short length":     {0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01},
+	}
+	for name, data := range tests {
+		if got := jpegOrientation(data); got != 1 {
+			t.Errorf("%s: orientation = %d, want 1", name, got)
+		}
+	}
+}
+
+func TestOrientImage(t *testing.T) {
+	// A 3×2 image with a distinct color per pixel, so every transform lands pixels in a known place
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			src.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), A: 255})
+		}
+	}
+
+	// Where the source's top left pixel (0,0) and top right pixel (2,0) end up for each orientation
+	tests := []struct {
+		orientation       int
+		w, h              int
+		topLeft, topRight image.Point
+	}{
+		{1, 3, 2, image.Pt(0, 0), image.Pt(2, 0)},
+		{2, 3, 2, image.Pt(2, 0), image.Pt(0, 0)},
+		{3, 3, 2, image.Pt(2, 1), image.Pt(0, 1)},
+		{4, 3, 2, image.Pt(0, 1), image.Pt(2, 1)},
+		{5, 2, 3, image.Pt(0, 0), image.Pt(0, 2)},
+		{6, 2, 3, image.Pt(1, 0), image.Pt(1, 2)},
+		{7, 2, 3, image.Pt(1, 2), image.Pt(1, 0)},
+		{8, 2, 3, image.Pt(0, 2), image.Pt(0, 0)},
+	}
+
+	for _, tt := range tests {
+		got := orientImage(src, tt.orientation)
+		if got.Bounds().Dx() != tt.w || got.Bounds().Dy() != tt.h {
+			t.Errorf("orientation %d: size %v, want %dx%d", tt.orientation, got.Bounds().Size(), tt.w, tt.h)
+			continue
+		}
+		if c := color.NRGBAModel.Convert(got.At(tt.topLeft.X, tt.topLeft.Y)).(color.NRGBA); c.R != 0 || c.G != 0 {
+			t.Errorf("orientation %d: top left pixel is not at %v", tt.orientation, tt.topLeft)
+		}
+		if c := color.NRGBAModel.Convert(got.At(tt.topRight.X, tt.topRight.Y)).(color.NRGBA); c.R != 2 || c.G != 0 {
+			t.Errorf("orientation %d: top right pixel is not at %v", tt.orientation, tt.topRight)
+		}
+	}
+}
+
+func TestDecodeImageAppliesOrientation(t *testing.T) {
+	img, format, err := DecodeImage(jpegWithSegment(t, 40, 20, exifSegment(binary.LittleEndian, 6)))
+	if err != nil {
+		t.Fatalf("DecodeImage: %v", err)
+	}
+	if format != "jpeg" {
+		t.Errorf("format = %q", format)
+	}
+	if img.Bounds().Dx() != 20 || img.Bounds().Dy() != 40 {
+		t.Errorf("size = %v, want 20x40", img.Bounds().Size())
+	}
+
+	if _, _, err := DecodeImage([]byte("not an image")); err != ErrUnsupportedImage {
+		t.Errorf("DecodeImage of text = %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// JSONSchema describes the JSON form of value, which must be a struct or a pointer to one. Fields of value
+// that are set are given as defaults.
+func JSONSchema(value interface{}) map[string]interface{} {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	schema := typeSchema(v.Type())
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+
+	properties := schema["properties"].(map[string]interface{})
+	for name, field := range jsonFields(v.Type()) {
+		fieldValue := v.FieldByIndex(field.Index)
+		if !fieldValue.IsZero() {
+			properties[name].(map[string]interface{})["default"] = fieldValue.Interface()
+		}
+	}
+	return schema
+}
+
+// JSONFieldValue returns the field of a struct, or pointer to one, with the given JSON name
+func JSONFieldValue(value interface{}, name string) (reflect.Value, bool) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	field, ok := jsonFields(v.Type())[name]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	return v.FieldByIndex(field.Index), true
+}
+
+// jsonFields maps the JSON names of a struct's exported fields to the fields
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case t.Kind() == reflect.Struct:
+		properties := make(map[string]interface{})
+		for name, field := range jsonFields(t) {
+			properties[name] = typeSchema(field.Type)
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	default:
+		return map[string]interface{}{}
+	}
+}
@@ -0,0 +1,89 @@
+package helpers
+
+import (
+	"os"
+	"reflect"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is the locale of content's main fields, DEFAULT_LOCALE or "en"
+func DefaultLocale() string {
+	if locale := strings.TrimSpace(os.Getenv("DEFAULT_LOCALE")); locale != "" {
+		return locale
+	}
+	return "en"
+}
+
+// SupportedLocales are the locales content is served in, the default first. SUPPORTED_LOCALES lists them
+// comma separated and defaults to English and Burmese.
+func SupportedLocales() []string {
+	configured := os.Getenv("SUPPORTED_LOCALES")
+	if configured == "" {
+		configured = "en,my"
+	}
+
+	defaultLocale := DefaultLocale()
+	locales := []string{defaultLocale}
+	for _, locale := range strings.Split(configured, ",") {
+		locale = strings.TrimSpace(locale)
+		if locale != "" && locale != defaultLocale {
+			locales = append(locales, locale)
+		}
+	}
+	return locales
+}
+
+// IsSupportedLocale reports whether content may be translated into locale
+func IsSupportedLocale(locale string) bool {
+	for _, supported := range SupportedLocales() {
+		if supported == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateLocale picks the supported locale best matching lang, or failing that the Accept-Language header,
+// and falls back to the default locale
+func NegotiateLocale(lang string, acceptLanguage string) string {
+	supported := SupportedLocales()
+	tags := make([]language.Tag, 0, len(supported))
+	for _, locale := range supported {
+		tags = append(tags, language.Make(locale))
+	}
+	matcher := language.NewMatcher(tags)
+
+	if tag, err := language.Parse(lang); err == nil {
+		if _, index, confidence := matcher.Match(tag); confidence != language.No {
+			return supported[index]
+		}
+	}
+	if desired, _, err := language.ParseAcceptLanguage(acceptLanguage); err == nil && len(desired) > 0 {
+		if _, index, confidence := matcher.Match(desired...); confidence != language.No {
+			return supported[index]
+		}
+	}
+	return supported[0]
+}
+
+// ApplyTranslation overwrites the string fields of a struct, given by pointer, with the translated values
+// keyed by their JSON names. Empty translations are skipped so the original text shows instead.
+func ApplyTranslation(value interface{}, translated map[string]string) {
+	for name, text := range translated {
+		if text == "" {
+			continue
+		}
+		field, ok := JSONFieldValue(value, name)
+		if !ok || !field.CanSet() {
+			continue
+		}
+		switch {
+		case field.Kind() == reflect.String:
+			field.SetString(text)
+		case field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.String:
+			field.Set(reflect.ValueOf(&text))
+		}
+	}
+}
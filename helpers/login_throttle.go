@@ -0,0 +1,285 @@
+package helpers
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LoginAttemptStore keeps failed login counters and lockouts. The Mongo store shares state across
+// replicas; the memory store is enough for a single instance.
+type LoginAttemptStore interface {
+	// RecordFailure counts a failed attempt and returns the number of failures within the window
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int, error)
+	// Lock blocks attempts for the key until the given time
+	Lock(ctx context.Context, key string, until time.Time) error
+	// LockedUntil returns when the key's lockout ends, or the zero time when it is not locked
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+	// Reset clears the failures and lockout for the key
+	Reset(ctx context.Context, key string) error
+}
+
+// BackoffPolicy allows FreeAttempts failures, then locks for BaseLockout, doubling with each
+// further failure up to MaxLockout. Failures older than Window are forgotten.
+type BackoffPolicy struct {
+	FreeAttempts int
+	BaseLockout  time.Duration
+	MaxLockout   time.Duration
+	Window       time.Duration
+}
+
+// Lockout returns how long to lock after the given number of failures
+func (p BackoffPolicy) Lockout(failures int) time.Duration {
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+	lockout := p.BaseLockout
+	for i := p.FreeAttempts + 1; i < failures; i++ {
+		lockout *= 2
+		if lockout >= p.MaxLockout {
+			return p.MaxLockout
+		}
+	}
+	return lockout
+}
+
+// LoginThrottle tracks failures per account and per client IP
+type LoginThrottle struct {
+	Store         LoginAttemptStore
+	AccountPolicy BackoffPolicy
+	IPPolicy      BackoffPolicy
+}
+
+func NewLoginThrottle(store LoginAttemptStore) *LoginThrottle {
+	return &LoginThrottle{
+		Store: store,
+		AccountPolicy: BackoffPolicy{
+			FreeAttempts: 5,
+			BaseLockout:  30 * time.Second,
+			MaxLockout:   time.Hour,
+			Window:       24 * time.Hour,
+		},
+		// An IP may legitimately serve many users, so it gets more slack before backing off
+		IPPolicy: BackoffPolicy{
+			FreeAttempts: 20,
+			BaseLockout:  time.Minute,
+			MaxLockout:   time.Hour,
+			Window:       time.Hour,
+		},
+	}
+}
+
+func AccountAttemptKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func IPAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns how long the caller must wait before trying again, zero when attempts are allowed
+func (t *LoginThrottle) Check(ctx context.Context, email string, ip string) (time.Duration, error) {
+	var wait time.Duration
+	for _, key := range []string{AccountAttemptKey(email), IPAttemptKey(ip)} {
+		lockedUntil, err := t.Store.LockedUntil(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if remaining := time.Until(lockedUntil); remaining > wait {
+			wait = remaining
+		}
+	}
+	return wait, nil
+}
+
+// RecordFailure counts a failed attempt against the account and IP and applies any lockout
+func (t *LoginThrottle) RecordFailure(ctx context.Context, email string, ip string) error {
+	targets := []struct {
+		key    string
+		policy BackoffPolicy
+	}{
+		{AccountAttemptKey(email), t.AccountPolicy},
+		{IPAttemptKey(ip), t.IPPolicy},
+	}
+
+	for _, target := range targets {
+		failures, err := t.Store.RecordFailure(ctx, target.key, target.policy.Window)
+		if err != nil {
+			return err
+		}
+		if lockout := target.policy.Lockout(failures); lockout > 0 {
+			if err := t.Store.Lock(ctx, target.key, time.Now().Add(lockout)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RecordSuccess clears the account's failures after a successful login
+func (t *LoginThrottle) RecordSuccess(ctx context.Context, email string) error {
+	return t.Store.Reset(ctx, AccountAttemptKey(email))
+}
+
+type loginAttempt struct {
+	Failures     int       `bson:"failures"`
+	Last_Failure time.Time `bson:"last_failure"`
+	Locked_Until time.Time `bson:"locked_until"`
+
+	// expires is when the memory store can forget the attempt: its failures have left the window and any
+	// lockout has ended
+	expires time.Time
+}
+
+// LoginAttemptExpiryField is when the Mongo store no longer needs an attempt: its failures have left the window
+// and any lockout has ended. A TTL index on it removes attempts for guessed emails and passing IPs.
+const LoginAttemptExpiryField = "expires_at"
+
+type mongoLoginAttemptStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoLoginAttemptStore(collection *mongo.Collection) LoginAttemptStore {
+	return &mongoLoginAttemptStore{collection: collection}
+}
+
+// mongoFailureUpdate counts a failure at now, restarting the count when the previous failure fell outside the
+// window, and keeps the attempt until the window or any lockout ends, whichever is later
+func mongoFailureUpdate(now time.Time, window time.Duration) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"failures": bson.M{"$cond": bson.A{
+				bson.M{"$lt": bson.A{bson.M{"$ifNull": bson.A{"$last_failure", time.Time{}}}, now.Add(-window)}},
+				1,
+				bson.M{"$add": bson.A{"$failures", 1}},
+			}},
+			"last_failure": now,
+			LoginAttemptExpiryField: bson.M{"$max": bson.A{
+				now.Add(window),
+				bson.M{"$ifNull": bson.A{"$locked_until", time.Time{}}},
+			}},
+		}}},
+	}
+}
+
+func (s *mongoLoginAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	update := mongoFailureUpdate(time.Now(), window)
+
+	var attempt loginAttempt
+	err := s.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": key},
+		update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&attempt)
+	return attempt.Failures, err
+}
+
+func (s *mongoLoginAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := s.collection.UpdateOne(ctx, bson.M{"_id": key}, bson.M{
+		"$set": bson.M{"locked_until": until},
+		"$max": bson.M{LoginAttemptExpiryField: until},
+	})
+	return err
+}
+
+func (s *mongoLoginAttemptStore) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	var attempt loginAttempt
+	err := s.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&attempt)
+	if err == mongo.ErrNoDocuments {
+		return time.Time{}, nil
+	}
+	return attempt.Locked_Until, err
+}
+
+func (s *mongoLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+// memorySweepInterval is how often the memory store drops attempts that have expired
+const memorySweepInterval = time.Minute
+
+type memoryLoginAttemptStore struct {
+	mutex     sync.Mutex
+	attempts  map[string]*loginAttempt
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryLoginAttemptStore() LoginAttemptStore {
+	return &memoryLoginAttemptStore{attempts: map[string]*loginAttempt{}, now: time.Now}
+}
+
+// sweep drops expired attempts, so keys from guessed emails and passing IPs do not pile up forever.
+// The caller holds the mutex.
+func (s *memoryLoginAttemptStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, attempt := range s.attempts {
+		if now.After(attempt.expires) {
+			delete(s.attempts, key)
+		}
+	}
+}
+
+func (s *memoryLoginAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		attempt = &loginAttempt{}
+		s.attempts[key] = attempt
+	}
+	if now.Sub(attempt.Last_Failure) > window {
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.Last_Failure = now
+	if expires := now.Add(window); expires.After(attempt.expires) {
+		attempt.expires = expires
+	}
+	return attempt.Failures, nil
+}
+
+func (s *memoryLoginAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if attempt, ok := s.attempts[key]; ok {
+		attempt.Locked_Until = until
+		if until.After(attempt.expires) {
+			attempt.expires = until
+		}
+	}
+	return nil
+}
+
+func (s *memoryLoginAttemptStore) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if attempt, ok := s.attempts[key]; ok && !s.now().After(attempt.expires) {
+		return attempt.Locked_Until, nil
+	}
+	return time.Time{}, nil
+}
+
+func (s *memoryLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
@@ -0,0 +1,143 @@
+package helpers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestBackoffPolicyLockout(t *testing.T) {
+	policy := BackoffPolicy{FreeAttempts: 5, BaseLockout: 30 * time.Second, MaxLockout: 5 * time.Minute, Window: time.Hour}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{5, 0},
+		{6, 30 * time.Second},
+		{7, time.Minute},
+		{8, 2 * time.Minute},
+		{9, 4 * time.Minute},
+		{10, 5 * time.Minute},
+		{50, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.Lockout(tt.failures); got != tt.want {
+			t.Errorf("Lockout(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottleLocksAccountAfterFreeAttempts(t *testing.T) {
+	ctx := context.Background()
+	throttle := NewLoginThrottle(NewMemoryLoginAttemptStore())
+
+	for i := 0; i < throttle.AccountPolicy.FreeAttempts; i++ {
+		if err := throttle.RecordFailure(ctx, "ada@example.com", "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if wait, _ := throttle.Check(ctx, "ada@example.com", "192.0.2.1"); wait != 0 {
+		t.Fatalf("locked after only the free attempts, wait %v", wait)
+	}
+
+	throttle.RecordFailure(ctx, "ada@example.com", "192.0.2.1")
+	wait, _ := throttle.Check(ctx, " ADA@example.com ", "198.51.100.7")
+	if wait <= 0 || wait > throttle.AccountPolicy.BaseLockout {
+		t.Fatalf("account lockout = %v, want up to %v from any IP", wait, throttle.AccountPolicy.BaseLockout)
+	}
+	if wait, _ := throttle.Check(ctx, "grace@example.com", "192.0.2.1"); wait != 0 {
+		t.Errorf("another account on the same IP was locked, wait %v", wait)
+	}
+
+	throttle.RecordSuccess(ctx, "ada@example.com")
+	if wait, _ := throttle.Check(ctx, "ada@example.com", "192.0.2.1"); wait != 0 {
+		t.Errorf("account still locked after a successful login, wait %v", wait)
+	}
+}
+
+func TestLoginThrottleLocksIPAcrossAccounts(t *testing.T) {
+	ctx := context.Background()
+	throttle := NewLoginThrottle(NewMemoryLoginAttemptStore())
+
+	// Spraying one attempt at many accounts never trips an account lockout, but does trip the IP's
+	for i := 0; i <= throttle.IPPolicy.FreeAttempts; i++ {
+		throttle.RecordFailure(ctx, "user"+string(rune('a'+i))+"@example.com", "192.0.2.1")
+	}
+	if wait, _ := throttle.Check(ctx, "new@example.com", "192.0.2.1"); wait <= 0 {
+		t.Error("IP was not locked after spraying accounts")
+	}
+	if wait, _ := throttle.Check(ctx, "new@example.com", "198.51.100.7"); wait != 0 {
+		t.Errorf("another IP was locked, wait %v", wait)
+	}
+}
+
+func TestMemoryLoginAttemptStoreForgetsOldFailures(t *testing.T) {
+	ctx := context.Background()
+	clock := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryLoginAttemptStore().(*memoryLoginAttemptStore)
+	store.now = func() time.Time { return clock }
+
+	store.RecordFailure(ctx, "account:ada@example.com", time.Hour)
+	if failures, _ := store.RecordFailure(ctx, "account:ada@example.com", time.Hour); failures != 2 {
+		t.Fatalf("failures = %d, want 2", failures)
+	}
+
+	clock = clock.Add(2 * time.Hour)
+	if failures, _ := store.RecordFailure(ctx, "account:ada@example.com", time.Hour); failures != 1 {
+		t.Errorf("failures outside the window were counted, got %d", failures)
+	}
+}
+
+func TestMemoryLoginAttemptStoreEvictsExpiredAttempts(t *testing.T) {
+	ctx := context.Background()
+	clock := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryLoginAttemptStore().(*memoryLoginAttemptStore)
+	store.now = func() time.Time { return clock }
+
+	for _, key := range []string{"account:a@example.com", "account:b@example.com", "ip:192.0.2.1"} {
+		store.RecordFailure(ctx, key, time.Hour)
+	}
+	// A lockout keeps its attempt around past the failure window
+	store.Lock(ctx, "ip:192.0.2.1", clock.Add(3*time.Hour))
+
+	clock = clock.Add(2 * time.Hour)
+	store.RecordFailure(ctx, "account:c@example.com", time.Hour)
+
+	store.mutex.Lock()
+	remaining := len(store.attempts)
+	_, locked := store.attempts["ip:192.0.2.1"]
+	store.mutex.Unlock()
+	if remaining != 2 || !locked {
+		t.Errorf("after the sweep %d attempts remain (locked IP kept: %v), want the locked IP and the new attempt", remaining, locked)
+	}
+
+	if until, _ := store.LockedUntil(ctx, "ip:192.0.2.1"); !until.Equal(clock.Add(time.Hour)) {
+		t.Errorf("LockedUntil = %v, want %v", until, clock.Add(time.Hour))
+	}
+}
+
+func TestMongoFailureUpdateSetsExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	update := mongoFailureUpdate(now, time.Hour)
+
+	set, ok := update[0][0].Value.(bson.M)
+	if !ok || update[0][0].Key != "$set" {
+		t.Fatalf("update = %v, want a $set stage", update)
+	}
+	expiry, ok := set[LoginAttemptExpiryField].(bson.M)
+	if !ok {
+		t.Fatalf("update does not set %s, so the TTL index would never remove the attempt", LoginAttemptExpiryField)
+	}
+	candidates := expiry["$max"].(bson.A)
+	if candidates[0] != now.Add(time.Hour) {
+		t.Errorf("expiry = %v, want the end of the window", candidates[0])
+	}
+	if !reflect.DeepEqual(candidates[1], bson.M{"$ifNull": bson.A{"$locked_until", time.Time{}}}) {
+		t.Errorf("expiry = %v, want any lockout kept until it ends", candidates[1])
+	}
+}
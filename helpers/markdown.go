@@ -0,0 +1,179 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+)
+
+// wordsPerMinute is the reading speed used for reading time estimates
+const wordsPerMinute = 200
+
+// Heading is an entry in a rendered document's table of contents
+type Heading struct {
+	Level int
+	ID    string
+	Text  string
+}
+
+var (
+	markdown = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		// Raw HTML is let through here and cleaned by the sanitizer afterwards
+		goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()),
+	)
+
+	markdownPolicy     *bluemonday.Policy
+	markdownPolicyOnce sync.Once
+)
+
+func sanitizer() *bluemonday.Policy {
+	markdownPolicyOnce.Do(func() {
+		markdownPolicy = bluemonday.UGCPolicy()
+		// Keep heading IDs so table of contents links work, and language classes for syntax highlighting
+		markdownPolicy.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{M}\p{N}_-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+		markdownPolicy.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+	})
+	return markdownPolicy
+}
+
+// RenderMarkdown converts Markdown to sanitized HTML and collects its headings for a table of contents
+func RenderMarkdown(source string) (string, []Heading, error) {
+	src := []byte(source)
+	parserContext := parser.NewContext(parser.WithIDs(&headingIDs{used: map[string]bool{}}))
+	document := markdown.Parser().Parse(text.NewReader(src), parser.WithContext(parserContext))
+
+	headings := []Heading{}
+	err := ast.Walk(document, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := node.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+
+		id, _ := heading.AttributeString("id")
+		idBytes, _ := id.([]byte)
+		headings = append(headings, Heading{
+			Level: heading.Level,
+			ID:    string(idBytes),
+			Text:  strings.TrimSpace(nodeText(heading, src)),
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := markdown.Renderer().Render(&buf, src, document); err != nil {
+		return "", nil, err
+	}
+	return sanitizer().Sanitize(buf.String()), headings, nil
+}
+
+// headingIDs generates heading IDs with Slugify, so headings in any script get a readable anchor rather than
+// goldmark's default, which drops everything but ASCII
+type headingIDs struct {
+	used map[string]bool
+}
+
+func (h *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	id := Slugify(string(value))
+	if id == "" {
+		id = "heading"
+	}
+	candidate := id
+	for i := 1; h.used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", id, i)
+	}
+	h.used[candidate] = true
+	return []byte(candidate)
+}
+
+func (h *headingIDs) Put(value []byte) {
+	h.used[string(value)] = true
+}
+
+// nodeText concatenates the text inside a node, skipping markup
+func nodeText(node ast.Node, source []byte) string {
+	var sb strings.Builder
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch n := child.(type) {
+		case *ast.Text:
+			sb.Write(n.Segment.Value(source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				sb.WriteByte(' ')
+			}
+		case *ast.String:
+			sb.Write(n.Value)
+		default:
+			sb.WriteString(nodeText(child, source))
+		}
+	}
+	return sb.String()
+}
+
+// PlainText strips all markup from rendered HTML and collapses whitespace
+func PlainText(renderedHTML string) string {
+	stripped := html.UnescapeString(bluemonday.StrictPolicy().Sanitize(renderedHTML))
+	return strings.Join(strings.Fields(stripped), " ")
+}
+
+// Excerpt shortens plain text to at most maxLength characters, breaking at a word boundary
+func Excerpt(plainText string, maxLength int) string {
+	runes := []rune(plainText)
+	if len(runes) <= maxLength {
+		return plainText
+	}
+
+	cut := maxLength
+	for cut > 0 && !unicode.IsSpace(runes[cut]) {
+		cut--
+	}
+	if cut == 0 {
+		cut = maxLength
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
+
+// ReadingTime estimates the minutes needed to read the text, at least one
+func ReadingTime(plainText string) int {
+	minutes := (len(strings.Fields(plainText)) + wordsPerMinute - 1) / wordsPerMinute
+	if minutes < 1 {
+		return 1
+	}
+	return minutes
+}
+
+// Slugify turns a title into a lowercase, hyphen separated URL slug. Letters and digits of every script are kept,
+// so a title in Burmese or Greek gets a slug in its own script instead of none at all.
+func Slugify(title string) string {
+	var sb strings.Builder
+	separate := false
+	for _, r := range strings.ToLower(title) {
+		if !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsDigit(r) {
+			separate = sb.Len() > 0
+			continue
+		}
+		if separate {
+			sb.WriteByte('-')
+			separate = false
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,97 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdownSanitizes(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		forbidden []string
+	}{
+		{"script tag", "Hello <script>alert(1)</script>", []string{"<script", "alert(1)"}},
+		{"event handler", `<img src="x.png" onerror="alert(1)">`, []string{"onerror"}},
+		{"event handler on heading", `<h2 onclick="alert(1)">Title</h2>`, []string{"onclick"}},
+		{"javascript link", "[click](javascript:alert(1))", []string{"javascript:"}},
+		{"javascript href", `<a href="javascript:alert(1)">click</a>`, []string{"javascript:"}},
+		{"encoded javascript href", `<a href="&#106;avascript:alert(1)">click</a>`, []string{"avascript:"}},
+		{"data url", `<a href="data:text/html;base64,PHNjcmlwdD4=">click</a>`, []string{"data:text/html"}},
+		{"iframe", `<iframe src="https://evil.example.com"></iframe>`, []string{"<iframe"}},
+		{"style attribute", `<p style="background:url(javascript:alert(1))">x</p>`, []string{"style=", "javascript:"}},
+		{"svg", `<svg><script>alert(1)</script></svg>`, []string{"<svg", "<script"}},
+		{"forged heading id", `<h2 id="x&quot; onmouseover=&quot;alert(1)">Title</h2>`, []string{`onmouseover="`}},
+		{"code class injection", "<pre><code class=\"language-go x\" onclick=\"alert(1)\">x</code></pre>", []string{"onclick", "language-go x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, _, err := RenderMarkdown(tt.source)
+			if err != nil {
+				t.Fatalf("RenderMarkdown: %v", err)
+			}
+			for _, forbidden := range tt.forbidden {
+				if strings.Contains(strings.ToLower(rendered), strings.ToLower(forbidden)) {
+					t.Errorf("output contains %q:\n%s", forbidden, rendered)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownKeepsSafeMarkup(t *testing.T) {
+	source := "# Intro\n\nSome **bold** text and [a link](https://example.com).\n\n```go\nfmt.Println(1)\n```\n"
+	rendered, headings, err := RenderMarkdown(source)
+	if err != nil {
+		t.Fatalf("RenderMarkdown: %v", err)
+	}
+
+	for _, want := range []string{`<h1 id="intro">Intro</h1>`, "<strong>bold</strong>", `href="https://example.com"`, `class="language-go"`} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("output is missing %q:\n%s", want, rendered)
+		}
+	}
+	if len(headings) != 1 || headings[0].ID != "intro" || headings[0].Level != 1 || headings[0].Text != "Intro" {
+		t.Errorf("headings = %+v", headings)
+	}
+}
+
+func TestRenderMarkdownHeadingIDs(t *testing.T) {
+	source := "## မင်္ဂလာပါ\n\n## Ελληνικά κείμενο\n\n## Setup\n\n## Setup\n\n## 🚀\n"
+	rendered, headings, err := RenderMarkdown(source)
+	if err != nil {
+		t.Fatalf("RenderMarkdown: %v", err)
+	}
+
+	want := []string{"မင်္ဂလာပါ", "ελληνικά-κείμενο", "setup", "setup-1", "heading"}
+	if len(headings) != len(want) {
+		t.Fatalf("got %d headings, want %d", len(headings), len(want))
+	}
+	for i, id := range want {
+		if headings[i].ID != id {
+			t.Errorf("heading %d id = %q, want %q", i, headings[i].ID, id)
+		}
+		// The sanitizer must keep the id the table of contents links to
+		if !strings.Contains(rendered, `id="`+id+`"`) {
+			t.Errorf("id %q was dropped from the output:\n%s", id, rendered)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Hello, World!":            "hello-world",
+		"  Go 1.22 -- what's new ": "go-1-22-what-s-new",
+		"Café au lait":             "café-au-lait",
+		"မင်္ဂလာပါ ကမ္ဘာ": "မင်္ဂလာပါ-ကမ္ဘာ",
+		"Привет мир":      "привет-мир",
+		"🚀🔥":              "",
+		"":                "",
+	}
+	for title, want := range tests {
+		if got := Slugify(title); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Argon2Params are the Argon2id cost settings encoded into every hash
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params are 64 MiB, 3 passes and 2 lanes, well above the OWASP minimum for Argon2id
+// (19 MiB, 2 passes, 1 lane). Changing them makes existing hashes upgrade on the next successful login.
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+const argon2idPrefix = "$argon2id$"
+
+// HashPassword hashes a password with Argon2id in the PHC string format
+func HashPassword(password string) (string, error) {
+	params := DefaultArgon2Params
+
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// CheckPassword verifies a password against an Argon2id hash or a bcrypt hash stored before Argon2id was used
+func CheckPassword(hashedPassword, plainPassword string) bool {
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(plainPassword))
+		return err == nil
+	}
+
+	params, salt, key, err := decodeArgon2Hash(hashedPassword)
+	if err != nil {
+		return false
+	}
+	otherKey := argon2.IDKey([]byte(plainPassword), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+// PasswordNeedsRehash reports whether a hash should be replaced after a successful login: every bcrypt hash, and
+// Argon2id hashes made with parameters other than the current defaults
+func PasswordNeedsRehash(hashedPassword string) bool {
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		return true
+	}
+
+	params, salt, key, err := decodeArgon2Hash(hashedPassword)
+	if err != nil {
+		return true
+	}
+	current := DefaultArgon2Params
+	return params.Memory != current.Memory ||
+		params.Iterations != current.Iterations ||
+		params.Parallelism != current.Parallelism ||
+		uint32(len(salt)) != current.SaltLength ||
+		uint32(len(key)) != current.KeyLength
+}
+
+func decodeArgon2Hash(hashedPassword string) (params Argon2Params, salt []byte, key []byte, err error) {
+	// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, fmt.Errorf("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, err
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, err
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, err
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, err
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
@@ -0,0 +1,153 @@
+package helpers
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var (
+	commonPasswords     map[string]struct{}
+	commonPasswordsOnce sync.Once
+)
+
+// Character classes a policy can require
+const (
+	CharClassLower  = "lower"
+	CharClassUpper  = "upper"
+	CharClassDigit  = "digit"
+	CharClassSymbol = "symbol"
+)
+
+// PasswordPolicy describes what new passwords must satisfy
+type PasswordPolicy struct {
+	MinLength       int
+	MaxLength       int
+	RequiredClasses []string
+	RejectCommon    bool
+}
+
+// PasswordPolicyFromEnv reads PASSWORD_MIN_LENGTH (default 8), PASSWORD_MAX_LENGTH (default 128),
+// PASSWORD_REQUIRED_CLASSES (comma separated lower, upper, digit, symbol; default lower,upper,digit)
+// and PASSWORD_REJECT_COMMON (default true)
+func PasswordPolicyFromEnv() PasswordPolicy {
+	policy := PasswordPolicy{
+		MinLength:       8,
+		MaxLength:       128,
+		RequiredClasses: []string{CharClassLower, CharClassUpper, CharClassDigit},
+		RejectCommon:    true,
+	}
+
+	if value, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && value > 0 {
+		policy.MinLength = value
+	}
+	if value, err := strconv.Atoi(os.Getenv("PASSWORD_MAX_LENGTH")); err == nil && value > 0 {
+		policy.MaxLength = value
+	}
+	if value, ok := os.LookupEnv("PASSWORD_REQUIRED_CLASSES"); ok {
+		policy.RequiredClasses = nil
+		for _, class := range strings.Split(value, ",") {
+			if class = strings.ToLower(strings.TrimSpace(class)); class != "" {
+				policy.RequiredClasses = append(policy.RequiredClasses, class)
+			}
+		}
+	}
+	if value, err := strconv.ParseBool(os.Getenv("PASSWORD_REJECT_COMMON")); err == nil {
+		policy.RejectCommon = value
+	}
+	return policy
+}
+
+// Validate returns an error describing the first rule the password breaks. The email, when given, may not
+// be reused as the password.
+func (p PasswordPolicy) Validate(password string, email string) error {
+	length := len([]rune(password))
+	if length < p.MinLength {
+		return fmt.Errorf("Password must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("Password must be at most %d characters long", p.MaxLength)
+	}
+
+	for _, class := range p.RequiredClasses {
+		if !containsCharClass(password, class) {
+			return fmt.Errorf("Password must contain at least one %s", charClassDescription(class))
+		}
+	}
+
+	normalized := strings.ToLower(password)
+	if email != "" {
+		localPart := strings.ToLower(strings.SplitN(email, "@", 2)[0])
+		if normalized == strings.ToLower(email) || normalized == localPart {
+			return errors.New("Password must not be the same as your email")
+		}
+	}
+	if p.RejectCommon && IsCommonPassword(normalized) {
+		return errors.New("Password is too common, please choose another one")
+	}
+	return nil
+}
+
+// IsCommonPassword reports whether the password is in the bundled list of commonly used passwords
+func IsCommonPassword(password string) bool {
+	commonPasswordsOnce.Do(func() {
+		commonPasswords = map[string]struct{}{}
+		for _, line := range strings.Split(commonPasswordList, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				commonPasswords[strings.ToLower(line)] = struct{}{}
+			}
+		}
+	})
+	_, ok := commonPasswords[strings.ToLower(password)]
+	return ok
+}
+
+func containsCharClass(password string, class string) bool {
+	for _, r := range password {
+		switch class {
+		case CharClassLower:
+			if unicode.IsLower(r) {
+				return true
+			}
+		case CharClassUpper:
+			if unicode.IsUpper(r) {
+				return true
+			}
+		case CharClassDigit:
+			if unicode.IsDigit(r) {
+				return true
+			}
+		case CharClassSymbol:
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r) {
+				return true
+			}
+		default:
+			// Unknown classes in the configuration are ignored rather than making every password fail
+			return true
+		}
+	}
+	return false
+}
+
+func charClassDescription(class string) string {
+	switch class {
+	case CharClassLower:
+		return "lowercase letter"
+	case CharClassUpper:
+		return "uppercase letter"
+	case CharClassDigit:
+		return "digit"
+	case CharClassSymbol:
+		return "symbol"
+	default:
+		return class
+	}
+}
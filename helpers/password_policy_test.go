@@ -0,0 +1,69 @@
+package helpers
+
+import "testing"
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:       8,
+		MaxLength:       20,
+		RequiredClasses: []string{CharClassLower, CharClassUpper, CharClassDigit},
+		RejectCommon:    true,
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		email    string
+		wantErr  bool
+	}{
+		{"valid", "Tr0ubadour", "", false},
+		{"too short", "Ab1", "", true},
+		{"length counts runes", "Ünïcödé1", "", false},
+		{"too long", "Abcdefghijklmnopqrst1", "", true},
+		{"no uppercase", "tr0ubadour", "", true},
+		{"no lowercase", "TR0UBADOUR", "", true},
+		{"no digit", "Troubadour", "", true},
+		{"same as email", "Jane.Doe1@Example.com", "jane.doe1@example.com", true},
+		{"same as email local part", "Jane.Doe1", "jane.doe1@example.com", true},
+		{"common password", "Password1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, tt.email)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) = %v, wantErr %v", tt.password, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicySymbolsAndUnknownClasses(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 1, RequiredClasses: []string{CharClassSymbol}}
+	if err := policy.Validate("abc def", ""); err == nil {
+		t.Error("a space counted as a symbol")
+	}
+	if err := policy.Validate("abc!def", ""); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	policy.RequiredClasses = []string{"emoji"}
+	if err := policy.Validate("abc", ""); err != nil {
+		t.Errorf("unknown class rejected the password: %v", err)
+	}
+}
+
+func TestPasswordPolicyFromEnv(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "12")
+	t.Setenv("PASSWORD_MAX_LENGTH", "not a number")
+	t.Setenv("PASSWORD_REQUIRED_CLASSES", " Digit, ,symbol")
+	t.Setenv("PASSWORD_REJECT_COMMON", "false")
+
+	policy := PasswordPolicyFromEnv()
+	if policy.MinLength != 12 || policy.MaxLength != 128 || policy.RejectCommon {
+		t.Errorf("unexpected policy %+v", policy)
+	}
+	if len(policy.RequiredClasses) != 2 || policy.RequiredClasses[0] != CharClassDigit || policy.RequiredClasses[1] != CharClassSymbol {
+		t.Errorf("required classes = %v", policy.RequiredClasses)
+	}
+}
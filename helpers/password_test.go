@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashPasswordRoundTrip(t *testing.T) {
+	hash, err := HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=2$") {
+		t.Errorf("unexpected hash format %q", hash)
+	}
+	if !CheckPassword(hash, "correct horse battery staple") {
+		t.Error("CheckPassword rejected the right password")
+	}
+	if CheckPassword(hash, "correct horse battery stapler") {
+		t.Error("CheckPassword accepted the wrong password")
+	}
+	if PasswordNeedsRehash(hash) {
+		t.Error("a hash made with the current parameters needs a rehash")
+	}
+
+	other, err := HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if other == hash {
+		t.Error("two hashes of the same password share a salt")
+	}
+}
+
+func TestCheckPasswordLegacyBcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !CheckPassword(string(legacy), "old-password") {
+		t.Error("CheckPassword rejected a valid bcrypt password")
+	}
+	if CheckPassword(string(legacy), "new-password") {
+		t.Error("CheckPassword accepted the wrong bcrypt password")
+	}
+	if !PasswordNeedsRehash(string(legacy)) {
+		t.Error("bcrypt hashes must be upgraded")
+	}
+}
+
+func TestPasswordNeedsRehashAfterParamsChange(t *testing.T) {
+	saved := DefaultArgon2Params
+	t.Cleanup(func() { DefaultArgon2Params = saved })
+
+	DefaultArgon2Params = Argon2Params{Memory: 8 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	weak, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PasswordNeedsRehash(weak) {
+		t.Fatal("hash made with the current parameters needs a rehash")
+	}
+
+	DefaultArgon2Params = saved
+	if !PasswordNeedsRehash(weak) {
+		t.Error("hash made with older parameters does not need a rehash")
+	}
+	// The old hash still verifies with the parameters encoded in it
+	if !CheckPassword(weak, "secret") {
+		t.Error("CheckPassword rejected a hash made with older parameters")
+	}
+}
+
+func TestCheckPasswordMalformedHash(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"$argon2id$",
+		"$argon2id$v=19$m=65536,t=3,p=2$salt",
+		"$argon2id$v=16$m=65536,t=3,p=2$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=3,p=2$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=65536,t=3,p=2$!!!$a2V5",
+	} {
+		if CheckPassword(hash, "") {
+			t.Errorf("CheckPassword accepted malformed hash %q", hash)
+		}
+		if !PasswordNeedsRehash(hash) {
+			t.Errorf("malformed hash %q does not need a rehash", hash)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// SitemapMaxURLs is the most URLs a single sitemap file may list
+const SitemapMaxURLs = 50000
+
+const sitemapNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// SitemapURL is a page, or for an index a sitemap file, and when it last changed
+type SitemapURL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name       `xml:"urlset"`
+	XMLNS   string         `xml:"xmlns,attr"`
+	URLs    []sitemapEntry `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name       `xml:"sitemapindex"`
+	XMLNS    string         `xml:"xmlns,attr"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+func sitemapEntries(urls []SitemapURL) []sitemapEntry {
+	entries := make([]sitemapEntry, 0, len(urls))
+	for _, url := range urls {
+		entry := sitemapEntry{Loc: url.Loc}
+		if !url.LastMod.IsZero() {
+			entry.LastMod = url.LastMod.UTC().Format(time.RFC3339)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Sitemap writes a sitemap listing the given pages
+func Sitemap(urls []SitemapURL) ([]byte, error) {
+	return marshalXML(sitemapURLSet{XMLNS: sitemapNS, URLs: sitemapEntries(urls)})
+}
+
+// SitemapIndex writes a sitemap index listing the given sitemap files
+func SitemapIndex(sitemaps []SitemapURL) ([]byte, error) {
+	return marshalXML(sitemapIndex{XMLNS: sitemapNS, Sitemaps: sitemapEntries(sitemaps)})
+}
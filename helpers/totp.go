@@ -0,0 +1,151 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters from RFC 6238, matching what authenticator apps expect by default
+const (
+	TOTPPeriod = 30
+	TOTPDigits = 6
+	// totpSkew is how many periods either side of the current one are accepted to tolerate clock drift
+	totpSkew = 1
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new random 160-bit secret encoded as unpadded base32
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base32NoPadding.EncodeToString(secret), nil
+}
+
+// TOTPStep returns the RFC 6238 time step that t falls in
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / TOTPPeriod
+}
+
+// TOTPCode computes the code for the given time step
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation (RFC 4226 section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod), nil
+}
+
+// ValidateTOTP checks a code against the secret at time t and returns the matched time step,
+// so callers can reject a code that has already been used
+func ValidateTOTP(secret string, code string, t time.Time) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	current := TOTPStep(t)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// ValidateTOTPAfter is ValidateTOTP for a user whose code at lastStep was the last one accepted. Codes from
+// that step or earlier are rejected, so each code can be used only once.
+func ValidateTOTPAfter(secret string, code string, t time.Time, lastStep int64) (int64, bool) {
+	step, ok := ValidateTOTP(secret, code, t)
+	if !ok || step <= lastStep {
+		return 0, false
+	}
+	return step, true
+}
+
+// TOTPProvisioningURI builds the otpauth:// URI that authenticator apps scan as a QR code
+func TOTPProvisioningURI(secret string, issuer string, account string) string {
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprint(TOTPDigits))
+	values.Set("period", fmt.Sprint(TOTPPeriod))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + values.Encode()
+}
+
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// GenerateRecoveryCodes returns n single-use codes formatted as xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		raw, err := recoveryCodeChars(rand.Reader, 10)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, string(raw[:5])+"-"+string(raw[5:]))
+	}
+	return codes, nil
+}
+
+// recoveryCodeChars draws n characters uniformly from the recovery code alphabet. Bytes at or above the
+// largest multiple of the alphabet size are discarded, since mapping them would favour the first letters.
+func recoveryCodeChars(random io.Reader, n int) ([]byte, error) {
+	limit := 256 - 256%len(recoveryCodeAlphabet)
+
+	chars := make([]byte, 0, n)
+	buf := make([]byte, n)
+	for len(chars) < n {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, err
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(chars) < n {
+				chars = append(chars, recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+			}
+		}
+	}
+	return chars, nil
+}
+
+// NormalizeRecoveryCode lowercases a recovery code and strips separators before hashing
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, " ", "")
+	code = strings.ReplaceAll(code, "-", "")
+	return code
+}
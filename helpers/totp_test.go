@@ -0,0 +1,204 @@
+package helpers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key from RFC 6238 appendix B, "12345678901234567890", in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	// The RFC lists eight digit codes; six digit codes are their last six digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		code, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode at %d: %v", tt.unix, err)
+		}
+		if code != tt.code {
+			t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPStep(now)
+	codeAt := func(step int64) string {
+		code, err := TOTPCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", codeAt(current), current, true},
+		{"previous step", codeAt(current - 1), current - 1, true},
+		{"next step", codeAt(current + 1), current + 1, true},
+		{"two steps behind", codeAt(current - 2), 0, false},
+		{"two steps ahead", codeAt(current + 2), 0, false},
+		{"spaces are ignored", codeAt(current)[:3] + " " + codeAt(current)[3:], current, true},
+		{"wrong code", "000000", 0, false},
+		{"too short", codeAt(current)[:5], 0, false},
+		{"too long", codeAt(current) + "0", 0, false},
+		{"empty", "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfc6238Secret, tt.code, now)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("ValidateTOTP(%q) = %d, %v, want %d, %v", tt.code, step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPRejectsInvalidSecret(t *testing.T) {
+	if _, ok := ValidateTOTP("not base32!", "123456", time.Now()); ok {
+		t.Error("a code was accepted for an invalid secret")
+	}
+}
+
+func TestValidateTOTPAfterRejectsReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := TOTPCode(rfc6238Secret, TOTPStep(now))
+
+	step, ok := ValidateTOTPAfter(rfc6238Secret, code, now, 0)
+	if !ok {
+		t.Fatal("first use of a code was rejected")
+	}
+	if _, ok := ValidateTOTPAfter(rfc6238Secret, code, now, step); ok {
+		t.Error("the same code was accepted twice")
+	}
+	// An older code still inside the window is also stale once a newer one was used
+	previous, _ := TOTPCode(rfc6238Secret, step-1)
+	if _, ok := ValidateTOTPAfter(rfc6238Secret, previous, now, step); ok {
+		t.Error("a code older than the last accepted one was accepted")
+	}
+}
+
+// TestTwoFactorEnrolAndLogin walks through enrolment and two logins with a fixed clock, the way the
+// two-factor handlers use these helpers
+func TestTwoFactorEnrolAndLogin(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri := TOTPProvisioningURI(secret, "Expenzo", "ada@example.com")
+	if !strings.HasPrefix(uri, "otpauth://totp/Expenzo:ada@example.com?") || !strings.Contains(uri, "secret="+secret) {
+		t.Fatalf("unexpected provisioning URI %s", uri)
+	}
+
+	clock := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	// Enrolment confirms the first code and remembers its step
+	code, _ := TOTPCode(secret, TOTPStep(clock))
+	lastStep, ok := ValidateTOTP(secret, code, clock)
+	if !ok {
+		t.Fatal("enrolment code was rejected")
+	}
+
+	// Signing in again within the same period cannot reuse that code
+	if _, ok := ValidateTOTPAfter(secret, code, clock.Add(10*time.Second), lastStep); ok {
+		t.Fatal("enrolment code was accepted again at login")
+	}
+
+	// The next period's code signs in
+	clock = clock.Add(TOTPPeriod * time.Second)
+	code, _ = TOTPCode(secret, TOTPStep(clock))
+	if lastStep, ok = ValidateTOTPAfter(secret, code, clock, lastStep); !ok {
+		t.Fatal("login code was rejected")
+	}
+
+	// After a long gap, a code from the current period still works
+	clock = clock.Add(time.Hour)
+	code, _ = TOTPCode(secret, TOTPStep(clock))
+	if _, ok := ValidateTOTPAfter(secret, code, clock, lastStep); !ok {
+		t.Fatal("login code after an hour was rejected")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes, want 10", len(codes))
+	}
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("code %q is not formatted as xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q was generated twice", code)
+		}
+		seen[code] = true
+
+		typed := " " + strings.ToUpper(code[:5]) + " " + code[6:] + " "
+		if NormalizeRecoveryCode(typed) != NormalizeRecoveryCode(code) {
+			t.Errorf("%q does not normalize to the same code as %q", typed, code)
+		}
+	}
+}
+
+func TestRecoveryCodeCharsAreUnbiased(t *testing.T) {
+	// Every byte below the rejection limit once maps each character the same number of times
+	limit := 256 - 256%len(recoveryCodeAlphabet)
+	random := make([]byte, 0, 256)
+	for b := 0; b < 256; b++ {
+		random = append(random, byte(b))
+	}
+
+	chars, err := recoveryCodeChars(bytes.NewReader(random), limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[byte]int)
+	for _, c := range chars {
+		counts[c]++
+	}
+	want := limit / len(recoveryCodeAlphabet)
+	for i := 0; i < len(recoveryCodeAlphabet); i++ {
+		if got := counts[recoveryCodeAlphabet[i]]; got != want {
+			t.Errorf("%q drawn %d times, want %d", recoveryCodeAlphabet[i], got, want)
+		}
+	}
+}
+
+func TestRecoveryCodeCharsRejectBiasedBytes(t *testing.T) {
+	// 248 to 255 would wrap onto the first letters, so they are skipped and more randomness is read
+	chars, err := recoveryCodeChars(bytes.NewReader([]byte{255, 248, 0, 247}), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(chars) != "a9" {
+		t.Errorf("recoveryCodeChars() = %q, want %q", chars, "a9")
+	}
+
+	if _, err := recoveryCodeChars(bytes.NewReader([]byte{250, 251}), 2); err == nil {
+		t.Error("running out of randomness did not return an error")
+	}
+}
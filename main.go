@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"portfolio/controllers"
+	"portfolio/database"
 	"portfolio/middleware"
+	"portfolio/models"
 	"portfolio/routes"
+	token "portfolio/tokens"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -17,6 +23,55 @@ func main() {
 		log.Fatalf("Error loading .env file")
 	}
 
+	if err := database.Ping(); err != nil {
+		log.Fatalf("Error connecting to the database: %v", err)
+	}
+
+	if err := controllers.ValidatePublicURLs(); err != nil {
+		log.Fatalf("Error in public URL configuration: %v", err)
+	}
+
+	if err := controllers.ConfigureMediaStorage(); err != nil {
+		log.Fatalf("Error configuring media storage: %v", err)
+	}
+
+	if err := token.LoadKeys(); err != nil {
+		log.Fatalf("Error loading token signing keys: %v", err)
+	}
+
+	seedCtx, cancelSeed := context.WithTimeout(context.Background(), 30*time.Second)
+	if err := controllers.SeedRoles(seedCtx); err != nil {
+		log.Fatalf("Error seeding roles: %v", err)
+	}
+	if err := controllers.EnsureTwoFactorIndexes(seedCtx); err != nil {
+		log.Fatalf("Error creating two-factor indexes: %v", err)
+	}
+	if err := controllers.EnsureLoginAttemptIndexes(seedCtx); err != nil {
+		log.Fatalf("Error creating login attempt indexes: %v", err)
+	}
+	if err := controllers.EnsurePostIndexes(seedCtx); err != nil {
+		log.Fatalf("Error creating post indexes: %v", err)
+	}
+	if err := controllers.EnsureMediaIndexes(seedCtx); err != nil {
+		log.Fatalf("Error creating media indexes: %v", err)
+	}
+	if err := controllers.EnsureLayoutRevisionIndexes(seedCtx); err != nil {
+		log.Fatalf("Error creating layout revision indexes: %v", err)
+	}
+	if err := controllers.MigrateVerifiedUsers(seedCtx); err != nil {
+		log.Fatalf("Error migrating verified users: %v", err)
+	}
+	if err := controllers.MigrateProjectTags(seedCtx); err != nil {
+		log.Fatalf("Error migrating project tags: %v", err)
+	}
+	if err := controllers.MigrateLayoutRevisions(seedCtx); err != nil {
+		log.Fatalf("Error migrating layout revisions: %v", err)
+	}
+	cancelSeed()
+
+	go controllers.StartAccountPurger(context.Background())
+	go controllers.StartScheduledPublisher(context.Background())
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8000"
@@ -38,27 +93,35 @@ func main() {
 			"https://expenzo.kyawswarlynn.com",
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-API-Key"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 	}))
 
+	rootRoutes := router.Group("/")
 	publicRoutes := router.Group("/portfolio/")
 	authenticatedRoutes := router.Group("/portfolio/")
-	authenticatedRoutes.Use(middleware.Authentication())
+	authenticatedRoutes.Use(middleware.Authentication()).Use(middleware.RequireAnyPermission(models.PortfolioAdminPermissions...))
 
 	expenseRoutes := router.Group("/portfolio/expense")
 	expenseRoutes.Use(middleware.Authentication())
 
 	expenseAdminRoutes := router.Group("/portfolio/expense")
-	expenseAdminRoutes.Use(middleware.Authentication()).Use(middleware.Authorization([]int{1, 2}))
+	expenseAdminRoutes.Use(middleware.Authentication()).Use(middleware.RequireAnyPermission(models.ExpenseAdminPermissions...))
 
+	routes.WellKnownRoutes(rootRoutes)
+	routes.SitemapRoutes(rootRoutes)
 	routes.VisitorRoutes(publicRoutes, authenticatedRoutes)
 	routes.AuthRoutes(publicRoutes, authenticatedRoutes)
 	routes.LayoutRoutes(publicRoutes, authenticatedRoutes)
 	routes.CertificateRoutes(publicRoutes, authenticatedRoutes)
 	routes.ServiceRoutes(publicRoutes, authenticatedRoutes)
 	routes.ProjectRoutes(publicRoutes, authenticatedRoutes)
+	routes.PostRoutes(publicRoutes, authenticatedRoutes)
+	routes.ResumeRoutes(publicRoutes, authenticatedRoutes)
+	routes.TranslationRoutes(authenticatedRoutes)
+	routes.FeedRoutes(publicRoutes)
+	routes.MediaRoutes(rootRoutes, authenticatedRoutes)
 	routes.EmailRoutes(publicRoutes, authenticatedRoutes)
 
 	// Expense App
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"portfolio/database"
+	"portfolio/helpers"
+	"portfolio/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var apiKeyCollection *mongo.Collection = database.PortfolioData(database.Client, "ApiKeys")
+var userCollection *mongo.Collection = database.PortfolioData(database.Client, "Users")
+
+// apiKeyTouchInterval limits how often a key's last used time is written
+const apiKeyTouchInterval = time.Minute
+
+// authenticateAPIKey resolves a personal API key to its owner and sets the same context values as a JWT,
+// plus the key's scopes which RequirePermission checks in addition to the owner's role
+func authenticateAPIKey(c *gin.Context, key string) *authError {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var apiKey models.ApiKey
+	err := apiKeyCollection.FindOne(ctx, bson.M{"key_hash": helpers.HashToken(key)}).Decode(&apiKey)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error retrieving API key: %v", err)
+		}
+		return &authError{http.StatusUnauthorized, "Invalid API key"}
+	}
+
+	now := time.Now()
+	if apiKey.Expires_At != nil && now.After(*apiKey.Expires_At) {
+		return &authError{http.StatusUnauthorized, "API key has expired"}
+	}
+
+	userID, err := primitive.ObjectIDFromHex(apiKey.User_ID)
+	if err != nil {
+		return &authError{http.StatusUnauthorized, "Invalid API key"}
+	}
+
+	var user models.User
+	if err := userCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		return &authError{http.StatusUnauthorized, "Invalid API key"}
+	}
+	if user.Suspended {
+		return &authError{http.StatusForbidden, "Account is suspended"}
+	}
+
+	if apiKey.Last_Used_At == nil || now.Sub(*apiKey.Last_Used_At) > apiKeyTouchInterval {
+		_, err = apiKeyCollection.UpdateOne(ctx, bson.M{"_id": apiKey.Key_ID}, bson.M{"$set": bson.M{"last_used_at": now}})
+		if err != nil {
+			log.Printf("Error updating API key: %v", err)
+		}
+	}
+
+	c.Set("email", user.Email)
+	c.Set("userId", user.User_ID.Hex())
+	c.Set("role", user.Role)
+	c.Set("apiKeyId", apiKey.Key_ID.Hex())
+	c.Set("apiKeyScopes", apiKey.Permissions)
+	return nil
+}
+
+// RequireInteractiveLogin rejects requests made with an API key or while impersonating, for account changes
+// that need the user's own login
+func RequireInteractiveLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("apiKeyId") != "" {
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "This action cannot be performed with an API key"})
+			c.Abort()
+			return
+		}
+		if c.GetString("impersonatorId") != "" {
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "This action cannot be performed while impersonating"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
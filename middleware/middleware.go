@@ -1,58 +1,205 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"portfolio/database"
+	"portfolio/helpers"
 	"portfolio/models"
 	token "portfolio/tokens"
 
 	"github.com/avct/uasurfer"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// Authentication middleware for validating JWT token
+var sessionCollection *mongo.Collection = database.PortfolioData(database.Client, "Sessions")
+var roleCollection *mongo.Collection = database.PortfolioData(database.Client, "Roles")
+var auditLogCollection *mongo.Collection = database.PortfolioData(database.Client, "AuditLogs")
+
+const rolePermissionsTTL = 30 * time.Second
+
+type cachedPermissions struct {
+	permissions []string
+	loadedAt    time.Time
+}
+
+var (
+	rolePermissionsMutex sync.Mutex
+	rolePermissionsCache = map[string]cachedPermissions{}
+)
+
+// sessionTouchInterval limits how often a session's last seen time is written
+const sessionTouchInterval = time.Minute
+
+// authError is why a request could not be authenticated
+type authError struct {
+	status  int
+	message string
+}
+
+// Authentication middleware for validating JWT token or personal API key
 func Authentication() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.Request.Header.Get("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header not provided"})
+		if authErr := authenticate(c); authErr != nil {
+			c.JSON(authErr.status, gin.H{"error": authErr.message})
 			c.Abort()
 			return
 		}
+		c.Next()
+	}
+}
 
-		// Parse Bearer token
-		fields := strings.Fields(authHeader)
-		if len(fields) != 2 || strings.ToLower(fields[0]) != "bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
-			return
+// OptionalAuthentication identifies the caller when credentials are sent but lets anonymous requests, and
+// requests with invalid credentials, through as anonymous
+func OptionalAuthentication() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Header.Get("X-API-Key") != "" || c.Request.Header.Get("Authorization") != "" {
+			authenticate(c)
 		}
+		c.Next()
+	}
+}
 
-		clientToken := fields[1]
-		claims, err := token.ValidateToken(clientToken)
-		if err != "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": err})
-			c.Abort()
-			return
+// authenticate checks the request's JWT or API key and sets the caller's details in the context
+func authenticate(c *gin.Context) *authError {
+	if apiKey := c.Request.Header.Get("X-API-Key"); apiKey != "" {
+		return authenticateAPIKey(c, apiKey)
+	}
+
+	authHeader := c.Request.Header.Get("Authorization")
+	if authHeader == "" {
+		return &authError{http.StatusUnauthorized, "Authorization header not provided"}
+	}
+
+	// Parse Bearer token
+	fields := strings.Fields(authHeader)
+	if len(fields) != 2 || strings.ToLower(fields[0]) != "bearer" {
+		return &authError{http.StatusUnauthorized, "Invalid authorization header format"}
+	}
+
+	clientToken := fields[1]
+	if strings.HasPrefix(clientToken, helpers.APIKeyPrefix) {
+		return authenticateAPIKey(c, clientToken)
+	}
+
+	claims, err := token.ValidateToken(clientToken)
+	if err != "" {
+		return &authError{http.StatusUnauthorized, err}
+	}
+
+	session, ok := validateSession(claims.Session_ID)
+	if !ok {
+		return &authError{http.StatusUnauthorized, "Session has been revoked"}
+	}
+
+	if claims.User_ID != "" && isUserSuspended(claims.User_ID) {
+		return &authError{http.StatusForbidden, "Account is suspended"}
+	}
+
+	// Set user information in context
+	c.Set("email", claims.Email)
+	c.Set("userId", claims.User_ID)
+	c.Set("role", claims.Role)
+	c.Set("sessionId", claims.Session_ID)
+	if session.Impersonator_ID != "" {
+		c.Set("impersonatorId", session.Impersonator_ID)
+		auditImpersonatedRequest(c, session)
+	}
+	return nil
+}
+
+// isUserSuspended reports whether the user's account has been suspended by an admin
+func isUserSuspended(userID string) bool {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	err = userCollection.FindOne(ctx, bson.M{"_id": objID}, options.FindOne().SetProjection(bson.M{"suspended": 1})).Decode(&user)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error retrieving user: %v", err)
 		}
+		return false
+	}
+	return user.Suspended
+}
 
-		// Set user information in context
-		c.Set("email", claims.Email)
-		c.Set("userId", claims.User_ID)
-		c.Set("role", claims.Role)
-		c.Next()
+// auditImpersonatedRequest records every change an admin makes while impersonating a user
+func auditImpersonatedRequest(c *gin.Context, session models.Session) {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := auditLogCollection.InsertOne(ctx, models.AuditLog{
+		Audit_ID:   primitive.NewObjectID(),
+		Action:     models.AuditImpersonationRequest,
+		Actor_ID:   session.Impersonator_ID,
+		Target_ID:  session.User_ID,
+		Details:    c.Request.Method + " " + c.Request.URL.Path,
+		IP:         c.ClientIP(),
+		Created_At: time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error writing audit log: %v", err)
+	}
+}
+
+// validateSession reports whether the session behind a token is still active and records activity on it
+func validateSession(sessionID string) (models.Session, bool) {
+	var session models.Session
+
+	objID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return session, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = sessionCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&session)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error retrieving session: %v", err)
+		}
+		return session, false
 	}
+	if session.Revoked {
+		return session, false
+	}
+
+	now := time.Now()
+	if now.Sub(session.Last_Seen_At) > sessionTouchInterval {
+		_, err = sessionCollection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"last_seen_at": now}})
+		if err != nil {
+			log.Printf("Error updating session: %v", err)
+		}
+	}
+	return session, true
 }
 
-// Authorization middleware to check roles
-func Authorization(roles []int) gin.HandlerFunc {
+// RequirePermission middleware to check that the caller's role grants a permission
+func RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRoleFromMdw, exists := c.Get("role")
 		if !exists {
@@ -61,39 +208,123 @@ func Authorization(roles []int) gin.HandlerFunc {
 			return
 		}
 
-		userRole, ok := userRoleFromMdw.(int)
+		userRole, ok := userRoleFromMdw.(string)
 		if !ok {
 			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Invalid role format"})
 			c.Abort()
 			return
 		}
 
-		isAuthorized := false
-		for _, role := range roles {
-			if userRole == role {
-				isAuthorized = true
-				break
-			}
+		permissions, err := RolePermissions(userRole)
+		if err != nil {
+			log.Printf("Error retrieving role permissions: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error checking permissions"})
+			c.Abort()
+			return
 		}
 
-		if !isAuthorized {
+		if !HasPermission(permissions, permission) {
 			c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "Access denied"})
 			c.Abort()
 			return
 		}
 
+		// API keys are further limited to the scopes chosen when they were created
+		if scopes, ok := c.Get("apiKeyScopes"); ok && !HasPermission(scopes.([]string), permission) {
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "API key does not have the required scope"})
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
 
-func VisitorDataMiddleware() gin.HandlerFunc {
+// RequireAnyPermission middleware lets through callers holding at least one of the permissions. Route groups
+// use it as a default guard, so a route missing its own RequirePermission is still closed to ordinary users.
+func RequireAnyPermission(permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userAgent := c.Request.UserAgent()
+		for _, permission := range permissions {
+			if CallerHasPermission(c, permission) {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "Access denied"})
+		c.Abort()
+	}
+}
+
+// CallerHasPermission reports whether the authenticated caller, if any, holds the permission. It is for handlers
+// behind OptionalAuthentication that show more to privileged callers.
+func CallerHasPermission(c *gin.Context, permission string) bool {
+	role := c.GetString("role")
+	if role == "" {
+		return false
+	}
 
-		deviceType := "Desktop"
-		if strings.Contains(strings.ToLower(userAgent), "mobile") {
-			deviceType = "Mobile"
+	permissions, err := RolePermissions(role)
+	if err != nil {
+		log.Printf("Error retrieving role permissions: %v", err)
+		return false
+	}
+	if !HasPermission(permissions, permission) {
+		return false
+	}
+
+	if scopes, ok := c.Get("apiKeyScopes"); ok && !HasPermission(scopes.([]string), permission) {
+		return false
+	}
+	return true
+}
+
+// HasPermission reports whether permission is in the list
+func HasPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
 		}
+	}
+	return false
+}
+
+// RolePermissions returns the permissions granted to a role. Lookups are cached briefly to avoid
+// a database round trip on every request; unknown roles have no permissions.
+func RolePermissions(roleName string) ([]string, error) {
+	rolePermissionsMutex.Lock()
+	cached, ok := rolePermissionsCache[roleName]
+	rolePermissionsMutex.Unlock()
+	if ok && time.Since(cached.loadedAt) < rolePermissionsTTL {
+		return cached.permissions, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var role models.Role
+	err := roleCollection.FindOne(ctx, bson.M{"_id": roleName}).Decode(&role)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	rolePermissionsMutex.Lock()
+	rolePermissionsCache[roleName] = cachedPermissions{permissions: role.Permissions, loadedAt: time.Now()}
+	rolePermissionsMutex.Unlock()
+	return role.Permissions, nil
+}
+
+// InvalidateRolePermissions drops cached role permissions after roles are edited
+func InvalidateRolePermissions() {
+	rolePermissionsMutex.Lock()
+	rolePermissionsCache = map[string]cachedPermissions{}
+	rolePermissionsMutex.Unlock()
+}
+
+func VisitorDataMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userAgent := c.Request.UserAgent()
+		deviceType := DeviceType(userAgent)
 
 		ip := c.ClientIP()
 		if ip == "::1" || ip == "127.0.0.1" {
@@ -148,6 +379,14 @@ func GetCountryFromIP(ip string) (string, error) {
 	return "Unknown", nil
 }
 
+// DeviceType classifies a User-Agent string as Mobile or Desktop
+func DeviceType(userAgent string) string {
+	if strings.Contains(strings.ToLower(userAgent), "mobile") {
+		return "Mobile"
+	}
+	return "Desktop"
+}
+
 // ParseUserAgent parses the User-Agent string for browser and OS
 func ParseUserAgent(userAgent string) (string, string) {
 	ua := uasurfer.Parse(userAgent)
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withRolePermissions caches role permissions so the checks below never need the database
+func withRolePermissions(t *testing.T, roles map[string][]string) {
+	t.Helper()
+	rolePermissionsMutex.Lock()
+	for role, permissions := range roles {
+		rolePermissionsCache[role] = cachedPermissions{permissions: permissions, loadedAt: time.Now()}
+	}
+	rolePermissionsMutex.Unlock()
+	t.Cleanup(InvalidateRolePermissions)
+}
+
+// serveAs runs a request through the handlers as a caller with the given role and API key scopes
+func serveAs(role string, scopes []string, handlers ...gin.HandlerFunc) int {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if role != "" {
+			c.Set("role", role)
+		}
+		if scopes != nil {
+			c.Set("apiKeyScopes", scopes)
+		}
+	})
+	router.GET("/", append(handlers, func(c *gin.Context) { c.Status(http.StatusOK) })...)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	return recorder.Code
+}
+
+func TestRequirePermission(t *testing.T) {
+	withRolePermissions(t, map[string][]string{
+		"editor": {"portfolio:edit"},
+		"user":   {"expenses:read"},
+		"nobody": nil,
+	})
+
+	tests := []struct {
+		name   string
+		role   string
+		scopes []string
+		want   int
+	}{
+		{"role grants the permission", "editor", nil, http.StatusOK},
+		{"role lacks the permission", "user", nil, http.StatusForbidden},
+		{"unknown role", "nobody", nil, http.StatusForbidden},
+		{"no role", "", nil, http.StatusUnauthorized},
+		{"API key scoped to the permission", "editor", []string{"portfolio:edit"}, http.StatusOK},
+		{"API key scoped elsewhere", "editor", []string{"expenses:read"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serveAs(tt.role, tt.scopes, RequirePermission("portfolio:edit")); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireAnyPermission(t *testing.T) {
+	withRolePermissions(t, map[string][]string{
+		"editor": {"portfolio:edit"},
+		"reader": {"messages:read"},
+		"user":   {"expenses:read", "expenses:write"},
+	})
+	guard := RequireAnyPermission("portfolio:edit", "messages:read", "visitors:read")
+
+	tests := []struct {
+		name   string
+		role   string
+		scopes []string
+		want   int
+	}{
+		{"holds the first permission", "editor", nil, http.StatusOK},
+		{"holds another permission", "reader", nil, http.StatusOK},
+		{"ordinary user", "user", nil, http.StatusForbidden},
+		{"no role", "", nil, http.StatusForbidden},
+		{"API key without an admin scope", "editor", []string{"expenses:read"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serveAs(tt.role, tt.scopes, guard); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
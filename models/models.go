@@ -38,62 +38,151 @@ type LoginDetails struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// Translations holds translated text of a document's fields, keyed by locale and then by the field's JSON
+// name. The document's own fields hold the text in the default locale.
+type Translations map[string]map[string]string
+
 type AboutMe struct {
-	Name        string `json:"name" bson:"name"`
-	Role        string `json:"role" bson:"role"`
-	Description string `json:"description" bson:"description"`
-	Github      string `json:"github" bson:"github"`
-	LinkedIn    string `json:"linkedIn" bson:"linkedIn"`
-	Facebook    string `json:"facebook" bson:"facebook"`
-	Telegram    string `json:"telegram" bson:"telegram"`
-	Image       string `json:"image" bson:"image"`
-	View_Count  int    `json:"view_count" bson:"view_count"`
-	T1          string `json:"t1" bson:"t1"`
-	T2          string `json:"t2" bson:"t2"`
+	Name         string       `json:"name" bson:"name"`
+	Role         string       `json:"role" bson:"role"`
+	Description  string       `json:"description" bson:"description"`
+	Github       string       `json:"github" bson:"github"`
+	LinkedIn     string       `json:"linkedIn" bson:"linkedIn"`
+	Facebook     string       `json:"facebook" bson:"facebook"`
+	Telegram     string       `json:"telegram" bson:"telegram"`
+	Image        string       `json:"image" bson:"image"`
+	Translations Translations `json:"translations,omitempty" bson:"translations,omitempty"`
+	View_Count   int          `json:"view_count" bson:"view_count"`
+	T1           string       `json:"t1" bson:"t1"`
+	T2           string       `json:"t2" bson:"t2"`
 }
 
 type ServiceInfo struct {
-	Title       string `json:"title" bson:"title"`
-	Description string `json:"description" bson:"description"`
+	Title        string       `json:"title" bson:"title"`
+	Description  string       `json:"description" bson:"description"`
+	Translations Translations `json:"translations,omitempty" bson:"translations,omitempty"`
 }
 
 type ProjectInfo struct {
-	Title       string `json:"title" bson:"title"`
-	Description string `json:"description" bson:"description"`
+	Title        string       `json:"title" bson:"title"`
+	Description  string       `json:"description" bson:"description"`
+	Translations Translations `json:"translations,omitempty" bson:"translations,omitempty"`
 }
 
 type Blog struct {
-	Title       string `json:"title" bson:"title"`
-	SubTitle    string `json:"sub_title" bson:"sub_title"`
-	Description string `json:"description" bson:"description"`
-	Image       string `json:"image" bson:"image"`
-	Link        string `json:"link" bson:"link"`
+	Title        string       `json:"title" bson:"title"`
+	SubTitle     string       `json:"sub_title" bson:"sub_title"`
+	Description  string       `json:"description" bson:"description"`
+	Image        string       `json:"image" bson:"image"`
+	Link         string       `json:"link" bson:"link"`
+	Translations Translations `json:"translations,omitempty" bson:"translations,omitempty"`
+}
+
+// LayoutRevision is a saved version of a layout section. Every change is kept so older content can be
+// compared and restored; drafts are saved without going live.
+type LayoutRevision struct {
+	Revision_ID   primitive.ObjectID     `json:"_id" bson:"_id"`
+	Type          string                 `json:"type" bson:"type"`
+	Version       int                    `json:"version" bson:"version"`
+	Data          map[string]interface{} `json:"data" bson:"data"`
+	Draft         bool                   `json:"draft" bson:"draft"`
+	Restored_From int                    `json:"restored_from,omitempty" bson:"restored_from,omitempty"`
+	Author        string                 `json:"author" bson:"author"`
+	Created_At    time.Time              `json:"created_at" bson:"created_at"`
+}
+
+// Publishing states of portfolio content. Only published content is shown publicly; scheduled content
+// is published automatically once its publish time passes.
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+func IsValidContentStatus(status string) bool {
+	return status == StatusDraft || status == StatusScheduled || status == StatusPublished || status == StatusArchived
+}
+
+// Post is a blog post written in Markdown; Body_HTML, TOC and Reading_Time are derived from Body on save,
+// and Rendered holds the same for each translated body
+type Post struct {
+	Post_ID      primitive.ObjectID      `json:"_id" bson:"_id"`
+	Title        string                  `json:"title" bson:"title"`
+	Slug         string                  `json:"slug" bson:"slug"`
+	Excerpt      string                  `json:"excerpt" bson:"excerpt"`
+	Body         string                  `json:"body" bson:"body"`
+	Body_HTML    string                  `json:"body_html" bson:"body_html"`
+	TOC          []TOCEntry              `json:"toc" bson:"toc"`
+	Cover_Image  string                  `json:"cover_image" bson:"cover_image"`
+	Tags         []string                `json:"tags" bson:"tags"`
+	Reading_Time int                     `json:"reading_time" bson:"reading_time"`
+	Status       string                  `json:"status" bson:"status"`
+	Publish_At   *time.Time              `json:"publish_at" bson:"publish_at"`
+	Published_At *time.Time              `json:"published_at" bson:"published_at"`
+	Translations Translations            `json:"translations,omitempty" bson:"translations,omitempty"`
+	Rendered     map[string]RenderedPost `json:"-" bson:"rendered,omitempty"`
+	T1           string                  `json:"t1" bson:"t1"`
+	T2           string                  `json:"t2" bson:"t2"`
+	Created_At   time.Time               `json:"created_at" bson:"created_at"`
+	Updated_At   time.Time               `json:"updated_at" bson:"updated_at"`
+}
+
+// RenderedPost is what is derived from a post body in one locale
+type RenderedPost struct {
+	Body_HTML    string     `bson:"body_html"`
+	TOC          []TOCEntry `bson:"toc"`
+	Reading_Time int        `bson:"reading_time"`
+	Excerpt      string     `bson:"excerpt"`
+}
+
+// TOCEntry links to a heading in a rendered post
+type TOCEntry struct {
+	Level int    `json:"level" bson:"level"`
+	ID    string `json:"id" bson:"id"`
+	Text  string `json:"text" bson:"text"`
 }
 
 type Service struct {
-	Service_ID primitive.ObjectID `json:"_id" bson:"_id"`
-	Title      *string            `json:"title" bson:"title"`
-	Content    *string            `json:"content" bson:"content"`
-	Image      *string            `json:"image" bson:"image"`
-	T1         *string            `json:"t1" bson:"t1"`
-	T2         *string            `json:"t2" bson:"t2"`
-	Created_At time.Time          `json:"created_at" bson:"created_at"`
-	Updated_At time.Time          `json:"updated_at" bson:"updated_at"`
+	Service_ID   primitive.ObjectID `json:"_id" bson:"_id"`
+	Title        *string            `json:"title" bson:"title"`
+	Content      *string            `json:"content" bson:"content"`
+	Image        *string            `json:"image" bson:"image"`
+	Status       *string            `json:"status" bson:"status"`
+	Publish_At   *time.Time         `json:"publish_at" bson:"publish_at"`
+	Translations Translations       `json:"translations,omitempty" bson:"translations,omitempty"`
+	T1           *string            `json:"t1" bson:"t1"`
+	T2           *string            `json:"t2" bson:"t2"`
+	Created_At   time.Time          `json:"created_at" bson:"created_at"`
+	Updated_At   time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
+// Project is a portfolio entry. Tag mirrors the first of Tags for clients written before projects had several.
 type Project struct {
-	Project_ID  primitive.ObjectID `json:"_id" bson:"_id"`
-	Title       *string            `json:"title" bson:"title"`
-	Description *string            `json:"description" bson:"description"`
-	Role        *string            `json:"role" bson:"role"`
-	DemoLink    *string            `json:"demo_link" bson:"demo_link"`
-	CodeLink    *string            `json:"code_link" bson:"code_link"`
-	Tag         *string            `json:"tag" bson:"tag"`
-	Image       *string            `json:"image" bson:"image"`
-	T1          *string            `json:"t1" bson:"t1"`
-	T2          *string            `json:"t2" bson:"t2"`
-	Created_At  time.Time          `json:"created_at" bson:"created_at"`
-	Updated_At  time.Time          `json:"updated_at" bson:"updated_at"`
+	Project_ID   primitive.ObjectID `json:"_id" bson:"_id"`
+	Title        *string            `json:"title" bson:"title"`
+	Description  *string            `json:"description" bson:"description"`
+	Role         *string            `json:"role" bson:"role"`
+	DemoLink     *string            `json:"demo_link" bson:"demo_link"`
+	CodeLink     *string            `json:"code_link" bson:"code_link"`
+	Tag          *string            `json:"tag" bson:"tag"`
+	Tags         []string           `json:"tags" bson:"tags"`
+	Featured     bool               `json:"featured" bson:"featured"`
+	Sort_Order   int                `json:"sort_order" bson:"sort_order"`
+	Image        *string            `json:"image" bson:"image"`
+	Status       *string            `json:"status" bson:"status"`
+	Publish_At   *time.Time         `json:"publish_at" bson:"publish_at"`
+	Translations Translations       `json:"translations,omitempty" bson:"translations,omitempty"`
+	T1           *string            `json:"t1" bson:"t1"`
+	T2           *string            `json:"t2" bson:"t2"`
+	Created_At   time.Time          `json:"created_at" bson:"created_at"`
+	Updated_At   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// TagCount is how many projects carry a tag
+type TagCount struct {
+	Tag   string `json:"tag" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
 }
 
 type Certificate struct {
@@ -102,12 +191,96 @@ type Certificate struct {
 	Content        *string            `json:"content" bson:"content"`
 	Image          *string            `json:"image" bson:"image"`
 	DemoLink       *string            `json:"demo_link" bson:"demo_link"`
+	Status         *string            `json:"status" bson:"status"`
+	Publish_At     *time.Time         `json:"publish_at" bson:"publish_at"`
+	Translations   Translations       `json:"translations,omitempty" bson:"translations,omitempty"`
 	T1             *string            `json:"t1" bson:"t1"`
 	T2             *string            `json:"t2" bson:"t2"`
 	Created_At     time.Time          `json:"created_at" bson:"created_at"`
 	Updated_At     time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
+// Experience is a position on the resume. End_Date is nil while the position is current.
+type Experience struct {
+	Experience_ID primitive.ObjectID `json:"_id" bson:"_id"`
+	Company       string             `json:"company" bson:"company"`
+	Company_URL   string             `json:"company_url" bson:"company_url"`
+	Role          string             `json:"role" bson:"role"`
+	Location      string             `json:"location" bson:"location"`
+	Start_Date    time.Time          `json:"start_date" bson:"start_date"`
+	End_Date      *time.Time         `json:"end_date" bson:"end_date"`
+	Description   string             `json:"description" bson:"description"`
+	Highlights    []string           `json:"highlights" bson:"highlights"`
+	Sort_Order    int                `json:"sort_order" bson:"sort_order"`
+	Status        *string            `json:"status" bson:"status"`
+	Publish_At    *time.Time         `json:"publish_at" bson:"publish_at"`
+	Translations  Translations       `json:"translations,omitempty" bson:"translations,omitempty"`
+	Created_At    time.Time          `json:"created_at" bson:"created_at"`
+	Updated_At    time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Education is a school or course on the resume. End_Date is nil while still studying.
+type Education struct {
+	Education_ID primitive.ObjectID `json:"_id" bson:"_id"`
+	Institution  string             `json:"institution" bson:"institution"`
+	Degree       string             `json:"degree" bson:"degree"`
+	Field        string             `json:"field" bson:"field"`
+	Location     string             `json:"location" bson:"location"`
+	Start_Date   time.Time          `json:"start_date" bson:"start_date"`
+	End_Date     *time.Time         `json:"end_date" bson:"end_date"`
+	Grade        string             `json:"grade" bson:"grade"`
+	Description  string             `json:"description" bson:"description"`
+	Highlights   []string           `json:"highlights" bson:"highlights"`
+	Sort_Order   int                `json:"sort_order" bson:"sort_order"`
+	Status       *string            `json:"status" bson:"status"`
+	Publish_At   *time.Time         `json:"publish_at" bson:"publish_at"`
+	Translations Translations       `json:"translations,omitempty" bson:"translations,omitempty"`
+	Created_At   time.Time          `json:"created_at" bson:"created_at"`
+	Updated_At   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Skill is a skill on the resume, grouped by Category. Proficiency runs from 1 to 5, or 0 when not rated.
+type Skill struct {
+	Skill_ID     primitive.ObjectID `json:"_id" bson:"_id"`
+	Name         string             `json:"name" bson:"name"`
+	Category     string             `json:"category" bson:"category"`
+	Proficiency  int                `json:"proficiency" bson:"proficiency"`
+	Years        float64            `json:"years" bson:"years"`
+	Sort_Order   int                `json:"sort_order" bson:"sort_order"`
+	Status       *string            `json:"status" bson:"status"`
+	Publish_At   *time.Time         `json:"publish_at" bson:"publish_at"`
+	Translations Translations       `json:"translations,omitempty" bson:"translations,omitempty"`
+	Created_At   time.Time          `json:"created_at" bson:"created_at"`
+	Updated_At   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Media is an uploaded image. Every file it was saved as, the full size image and its resized and WebP
+// variants, is named after the hash of the upload.
+type Media struct {
+	Media_ID     primitive.ObjectID `json:"_id" bson:"_id"`
+	Hash         string             `json:"hash" bson:"hash"`
+	Filename     string             `json:"filename" bson:"filename"`
+	Alt          string             `json:"alt" bson:"alt"`
+	Content_Type string             `json:"content_type" bson:"content_type"`
+	Width        int                `json:"width" bson:"width"`
+	Height       int                `json:"height" bson:"height"`
+	Size         int                `json:"size" bson:"size"`
+	URL          string             `json:"url" bson:"url"`
+	Variants     []MediaVariant     `json:"variants" bson:"variants"`
+	Uploaded_By  string             `json:"uploaded_by" bson:"uploaded_by"`
+	Created_At   time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// MediaVariant is one stored file of an uploaded image
+type MediaVariant struct {
+	Key          string `json:"key" bson:"key"`
+	URL          string `json:"url" bson:"url"`
+	Content_Type string `json:"content_type" bson:"content_type"`
+	Width        int    `json:"width" bson:"width"`
+	Height       int    `json:"height" bson:"height"`
+	Size         int    `json:"size" bson:"size"`
+}
+
 type Message struct {
 	Message_ID  primitive.ObjectID `json:"_id" bson:"_id"`
 	Name        *string            `json:"name" bson:"name"`
@@ -121,17 +294,204 @@ type Message struct {
 	Updated_At  time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
+// Built-in role names. Roles are stored in the Roles collection and map to a set of permissions;
+// these are seeded on startup and replace the numeric roles 0-3 that were used previously.
+const (
+	RoleUser           = "user"
+	RoleAdmin          = "admin"
+	RoleSuperAdmin     = "super_admin"
+	RolePortfolioAdmin = "portfolio_admin"
+)
+
+// Permissions that can be granted to a role
+const (
+	PermissionExpensesRead     = "expenses:read"
+	PermissionExpensesWrite    = "expenses:write"
+	PermissionUsersRead        = "users:read"
+	PermissionUsersWrite       = "users:write"
+	PermissionRolesWrite       = "roles:write"
+	PermissionSettingsWrite    = "settings:write"
+	PermissionPortfolioEdit    = "portfolio:edit"
+	PermissionMessagesRead     = "messages:read"
+	PermissionVisitorsRead     = "visitors:read"
+	PermissionUsersImpersonate = "users:impersonate"
+)
+
+var AllPermissions = []string{
+	PermissionExpensesRead,
+	PermissionExpensesWrite,
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionRolesWrite,
+	PermissionSettingsWrite,
+	PermissionPortfolioEdit,
+	PermissionMessagesRead,
+	PermissionVisitorsRead,
+	PermissionUsersImpersonate,
+}
+
+// PortfolioAdminPermissions are the permissions for managing the portfolio. Callers need at least one of them
+// to reach any authenticated portfolio route.
+var PortfolioAdminPermissions = []string{
+	PermissionPortfolioEdit,
+	PermissionMessagesRead,
+	PermissionVisitorsRead,
+}
+
+// ExpenseAdminPermissions are the permissions for administering the expense app. Callers need at least one of
+// them to reach any expense admin route.
+var ExpenseAdminPermissions = []string{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionRolesWrite,
+	PermissionSettingsWrite,
+	PermissionUsersImpersonate,
+}
+
+func IsValidPermission(permission string) bool {
+	for _, p := range AllPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type Role struct {
+	Name        string    `json:"name" bson:"_id"`
+	Description string    `json:"description" bson:"description"`
+	Permissions []string  `json:"permissions" bson:"permissions"`
+	System      bool      `json:"system" bson:"system"`
+	Created_At  time.Time `json:"created_at" bson:"created_at"`
+	Updated_At  time.Time `json:"updated_at" bson:"updated_at"`
+}
+
 type User struct {
-	User_ID    primitive.ObjectID `json:"_id" bson:"_id"`
-	Name       string             `json:"name" bson:"name"`
-	Email      string             `json:"email" bson:"email"`
-	Password   string             `json:"password" bson:"password"`
-	Avatar     string             `json:"avatar" bson:"avatar"`
-	Role       int                `json:"role" bson:"role"`
-	T1         string             `json:"t1" bson:"t1"`
-	T2         string             `json:"t2" bson:"t2"`
+	User_ID              primitive.ObjectID `json:"_id" bson:"_id"`
+	Name                 string             `json:"name" bson:"name"`
+	Email                string             `json:"email" bson:"email"`
+	Password             string             `json:"password" bson:"password"`
+	Avatar               string             `json:"avatar" bson:"avatar"`
+	Role                 string             `json:"role" bson:"role"`
+	Verified             bool               `json:"verified" bson:"verified"`
+	Pending_Email        string             `json:"pending_email" bson:"pending_email,omitempty"`
+	Verification_Token   string             `json:"-" bson:"verification_token,omitempty"`
+	Verification_Expires time.Time          `json:"-" bson:"verification_expires,omitempty"`
+	Verification_Sent_At time.Time          `json:"-" bson:"verification_sent_at,omitempty"`
+	TOTP_Enabled         bool               `json:"totp_enabled" bson:"totp_enabled"`
+	TOTP_Secret          string             `json:"-" bson:"totp_secret,omitempty"`
+	TOTP_Pending_Secret  string             `json:"-" bson:"totp_pending_secret,omitempty"`
+	TOTP_Last_Step       int64              `json:"-" bson:"totp_last_step,omitempty"`
+	Recovery_Codes       []string           `json:"-" bson:"recovery_codes,omitempty"`
+	Identities           []Identity         `json:"identities" bson:"identities,omitempty"`
+	Deletion_Scheduled   *time.Time         `json:"deletion_scheduled" bson:"deletion_scheduled,omitempty"`
+	Suspended            bool               `json:"suspended" bson:"suspended"`
+	Suspended_At         *time.Time         `json:"suspended_at" bson:"suspended_at,omitempty"`
+	Suspension_Reason    string             `json:"suspension_reason" bson:"suspension_reason,omitempty"`
+	T1                   string             `json:"t1" bson:"t1"`
+	T2                   string             `json:"t2" bson:"t2"`
+	Created_At           time.Time          `json:"created_at" bson:"created_at"`
+	Updated_At           time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Identity links a user to an account at an external OpenID provider
+type Identity struct {
+	Provider  string    `json:"provider" bson:"provider"`
+	Subject   string    `json:"subject" bson:"subject"`
+	Email     string    `json:"email" bson:"email"`
+	Linked_At time.Time `json:"linked_at" bson:"linked_at"`
+}
+
+// OAuthState remembers an in-flight social login between the redirect to the provider and the callback
+type OAuthState struct {
+	State_Hash    string    `json:"-" bson:"_id"`
+	Provider      string    `json:"provider" bson:"provider"`
+	Nonce         string    `json:"-" bson:"nonce"`
+	Code_Verifier string    `json:"-" bson:"code_verifier"`
+	Expires_At    time.Time `json:"expires_at" bson:"expires_at"`
+	// Link_User_ID is set when a signed in user is linking the provider to their own account
+	Link_User_ID *primitive.ObjectID `json:"-" bson:"link_user_id,omitempty"`
+}
+
+// UserResponse is the user as returned by the API, without the password hash or other secrets
+type UserResponse struct {
+	User_ID            primitive.ObjectID `json:"_id"`
+	Name               string             `json:"name"`
+	Email              string             `json:"email"`
+	Avatar             string             `json:"avatar"`
+	Role               string             `json:"role"`
+	Verified           bool               `json:"verified"`
+	Pending_Email      string             `json:"pending_email,omitempty"`
+	TOTP_Enabled       bool               `json:"totp_enabled"`
+	Identities         []Identity         `json:"identities"`
+	Deletion_Scheduled *time.Time         `json:"deletion_scheduled"`
+	Suspended          bool               `json:"suspended"`
+	Suspended_At       *time.Time         `json:"suspended_at"`
+	Suspension_Reason  string             `json:"suspension_reason"`
+	T1                 string             `json:"t1"`
+	T2                 string             `json:"t2"`
+	Created_At         time.Time          `json:"created_at"`
+	Updated_At         time.Time          `json:"updated_at"`
+}
+
+func NewUserResponse(user User) UserResponse {
+	return UserResponse{
+		User_ID:            user.User_ID,
+		Name:               user.Name,
+		Email:              user.Email,
+		Avatar:             user.Avatar,
+		Role:               user.Role,
+		Verified:           user.Verified,
+		Pending_Email:      user.Pending_Email,
+		TOTP_Enabled:       user.TOTP_Enabled,
+		Identities:         user.Identities,
+		Deletion_Scheduled: user.Deletion_Scheduled,
+		Suspended:          user.Suspended,
+		Suspended_At:       user.Suspended_At,
+		Suspension_Reason:  user.Suspension_Reason,
+		T1:                 user.T1,
+		T2:                 user.T2,
+		Created_At:         user.Created_At,
+		Updated_At:         user.Updated_At,
+	}
+}
+
+// Registration modes for the expense app
+const (
+	RegistrationOpen       = "open"
+	RegistrationInviteOnly = "invite_only"
+	RegistrationClosed     = "closed"
+)
+
+func IsValidRegistrationMode(mode string) bool {
+	return mode == RegistrationOpen || mode == RegistrationInviteOnly || mode == RegistrationClosed
+}
+
+type Settings struct {
+	Settings_ID                string    `json:"_id" bson:"_id"`
+	Require_Email_Verification bool      `json:"require_email_verification" bson:"require_email_verification"`
+	Registration_Mode          string    `json:"registration_mode" bson:"registration_mode"`
+	Updated_At                 time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Invite lets someone register while registration is invite only
+type Invite struct {
+	Invite_ID  primitive.ObjectID `json:"_id" bson:"_id"`
+	Code       string             `json:"code" bson:"code"`
+	Role       string             `json:"role" bson:"role"`
+	Max_Uses   int                `json:"max_uses" bson:"max_uses"`
+	Uses       int                `json:"uses" bson:"uses"`
+	Used_By    []InviteUse        `json:"used_by" bson:"used_by"`
+	Expires_At *time.Time         `json:"expires_at" bson:"expires_at"`
+	Revoked    bool               `json:"revoked" bson:"revoked"`
+	Created_By string             `json:"created_by" bson:"created_by"`
 	Created_At time.Time          `json:"created_at" bson:"created_at"`
-	Updated_At time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type InviteUse struct {
+	User_ID string    `json:"user_id" bson:"user_id"`
+	Email   string    `json:"email" bson:"email"`
+	Used_At time.Time `json:"used_at" bson:"used_at"`
 }
 
 type ExpenseCategory struct {
@@ -159,3 +519,68 @@ type ExpenseItem struct {
 	Created_At  time.Time          `json:"created_at" bson:"created_at"`
 	Updated_At  time.Time          `json:"updated_at" bson:"updated_at"`
 }
+
+type RefreshToken struct {
+	Token_ID    primitive.ObjectID `json:"_id" bson:"_id"`
+	Token_Hash  string             `json:"-" bson:"token_hash"`
+	Family_ID   string             `json:"family_id" bson:"family_id"`
+	Client      string             `json:"client" bson:"client"`
+	User_ID     string             `json:"user_id" bson:"user_id"`
+	Email       string             `json:"email" bson:"email"`
+	Role        string             `json:"role" bson:"role"`
+	Revoked     bool               `json:"revoked" bson:"revoked"`
+	Replaced_By string             `json:"-" bson:"replaced_by,omitempty"`
+	Expires_At  time.Time          `json:"expires_at" bson:"expires_at"`
+	Created_At  time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type Session struct {
+	Session_ID   primitive.ObjectID `json:"_id" bson:"_id"`
+	User_ID      string             `json:"user_id" bson:"user_id"`
+	Device       string             `json:"device" bson:"device"`
+	IP           string             `json:"ip" bson:"ip"`
+	Browser      string             `json:"browser" bson:"browser"`
+	OS           string             `json:"os" bson:"os"`
+	User_Agent   string             `json:"user_agent" bson:"user_agent"`
+	Revoked      bool               `json:"revoked" bson:"revoked"`
+	Revoked_At   *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+	Created_At   time.Time          `json:"created_at" bson:"created_at"`
+	Last_Seen_At time.Time          `json:"last_seen_at" bson:"last_seen_at"`
+	// Impersonator_ID is set on sessions an admin opened to act as the user
+	Impersonator_ID string `json:"impersonator_id,omitempty" bson:"impersonator_id,omitempty"`
+}
+
+// Audit actions recorded for administrative operations
+const (
+	AuditUserSuspend          = "user.suspend"
+	AuditUserReactivate       = "user.reactivate"
+	AuditUserDelete           = "user.delete"
+	AuditUserRoleUpdate       = "user.role_update"
+	AuditImpersonationStart   = "impersonation.start"
+	AuditImpersonationRequest = "impersonation.request"
+	AuditImpersonationStop    = "impersonation.stop"
+)
+
+type AuditLog struct {
+	Audit_ID    primitive.ObjectID `json:"_id" bson:"_id"`
+	Action      string             `json:"action" bson:"action"`
+	Actor_ID    string             `json:"actor_id" bson:"actor_id"`
+	Actor_Email string             `json:"actor_email" bson:"actor_email"`
+	Target_ID   string             `json:"target_id" bson:"target_id"`
+	Reason      string             `json:"reason,omitempty" bson:"reason,omitempty"`
+	Details     string             `json:"details,omitempty" bson:"details,omitempty"`
+	IP          string             `json:"ip" bson:"ip"`
+	Created_At  time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type ApiKey struct {
+	Key_ID       primitive.ObjectID `json:"_id" bson:"_id"`
+	User_ID      string             `json:"user_id" bson:"user_id"`
+	Name         string             `json:"name" bson:"name"`
+	Prefix       string             `json:"prefix" bson:"prefix"`
+	Key_Hash     string             `json:"-" bson:"key_hash"`
+	Permissions  []string           `json:"permissions" bson:"permissions"`
+	Expires_At   *time.Time         `json:"expires_at" bson:"expires_at"`
+	Last_Used_At *time.Time         `json:"last_used_at" bson:"last_used_at"`
+	Created_At   time.Time          `json:"created_at" bson:"created_at"`
+}
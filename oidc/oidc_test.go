@@ -0,0 +1,455 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "portfolio-client"
+
+// mockProvider is an OpenID provider served by httptest: discovery, JWKS and a token endpoint that hands out
+// whatever ID token the test set
+type mockProvider struct {
+	server *httptest.Server
+
+	mutex     sync.Mutex
+	keys      map[string]*rsa.PrivateKey
+	idToken   string
+	lastForm  url.Values
+	jwksHits  int
+	discovery map[string]string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+
+	m := &mockProvider{keys: map[string]*rsa.PrivateKey{"key-1": newRSAKey(t)}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		document := map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		}
+		for key, value := range m.discovery {
+			document[key] = value
+		}
+		json.NewEncoder(w).Encode(document)
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		m.jwksHits++
+		var keys []map[string]string
+		for kid, key := range m.keys {
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		m.lastForm = r.PostForm
+		if r.PostForm.Get("code") != "good-code" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     m.idToken,
+			"expires_in":   3600,
+		})
+	})
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func (m *mockProvider) config() Config {
+	return Config{
+		Issuer:      m.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: "https://app.example.com/oauth/callback",
+	}
+}
+
+func (m *mockProvider) discover(t *testing.T) *Provider {
+	t.Helper()
+	provider, err := Discover(context.Background(), m.config(), m.server.Client())
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	return provider
+}
+
+// validClaims are claims the mock provider would issue for a login started with the nonce
+func (m *mockProvider) validClaims(nonce string) *IDTokenClaims {
+	now := time.Now()
+	return &IDTokenClaims{
+		Nonce:         nonce,
+		Email:         "user@example.com",
+		EmailVerified: true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.server.URL,
+			Subject:   "subject-123",
+			Audience:  jwt.ClaimStrings{testClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestDiscover(t *testing.T) {
+	m := newMockProvider(t)
+	provider := m.discover(t)
+
+	if provider.Metadata.TokenEndpoint != m.server.URL+"/token" {
+		t.Errorf("token endpoint = %q", provider.Metadata.TokenEndpoint)
+	}
+	if strings.Join(provider.Config.Scopes, " ") != "openid email profile" {
+		t.Errorf("default scopes = %v", provider.Config.Scopes)
+	}
+}
+
+func TestDiscoverRejectsBadDocuments(t *testing.T) {
+	tests := []struct {
+		name      string
+		discovery map[string]string
+	}{
+		{"issuer mismatch", map[string]string{"issuer": "https://evil.example.com"}},
+		{"missing token endpoint", map[string]string{"token_endpoint": ""}},
+		{"missing jwks", map[string]string{"jwks_uri": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockProvider(t)
+			m.discovery = tt.discovery
+			if _, err := Discover(context.Background(), m.config(), m.server.Client()); err == nil {
+				t.Fatal("Discover accepted a bad document")
+			}
+		})
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	m := newMockProvider(t)
+	provider := m.discover(t)
+
+	verifier, err := NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := url.Parse(provider.AuthCodeURL("the-state", "the-nonce", verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := authURL.Query()
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          "https://app.example.com/oauth/callback",
+		"state":                 "the-state",
+		"nonce":                 "the-nonce",
+		"code_challenge":        CodeChallenge(verifier),
+		"code_challenge_method": "S256",
+	}
+	for key, value := range want {
+		if got := query.Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if query.Get("code_challenge") == verifier {
+		t.Error("the verifier itself was sent as the challenge")
+	}
+}
+
+func TestCodeChallengeRFC7636(t *testing.T) {
+	// Appendix B of RFC 7636
+	got := CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Errorf("CodeChallenge = %q, want %q", got, want)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	m := newMockProvider(t)
+	provider := m.discover(t)
+	m.idToken = sign(t, m.keys["key-1"], "key-1", m.validClaims("nonce"))
+
+	tokens, err := provider.Exchange(context.Background(), "good-code", "the-verifier")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if tokens.IDToken != m.idToken {
+		t.Error("Exchange did not return the provider's ID token")
+	}
+	if m.lastForm.Get("code_verifier") != "the-verifier" {
+		t.Errorf("code_verifier = %q", m.lastForm.Get("code_verifier"))
+	}
+	if m.lastForm.Get("grant_type") != "authorization_code" {
+		t.Errorf("grant_type = %q", m.lastForm.Get("grant_type"))
+	}
+
+	if _, err := provider.Exchange(context.Background(), "bad-code", "the-verifier"); err == nil {
+		t.Error("Exchange accepted a code the provider rejected")
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	m := newMockProvider(t)
+	provider := m.discover(t)
+	otherKey := newRSAKey(t)
+
+	tests := []struct {
+		name    string
+		token   func() string
+		nonce   string
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			token: func() string { return sign(t, m.keys["key-1"], "key-1", m.validClaims("n1")) },
+			nonce: "n1",
+		},
+		{
+			name:  "single key without kid",
+			token: func() string { return sign(t, m.keys["key-1"], "", m.validClaims("n1")) },
+			nonce: "n1",
+		},
+		{
+			name:    "signed with another key",
+			token:   func() string { return sign(t, otherKey, "key-1", m.validClaims("n1")) },
+			nonce:   "n1",
+			wantErr: true,
+		},
+		{
+			name: "wrong issuer",
+			token: func() string {
+				claims := m.validClaims("n1")
+				claims.Issuer = "https://evil.example.com"
+				return sign(t, m.keys["key-1"], "key-1", claims)
+			},
+			nonce:   "n1",
+			wantErr: true,
+		},
+		{
+			name: "wrong audience",
+			token: func() string {
+				claims := m.validClaims("n1")
+				claims.Audience = jwt.ClaimStrings{"someone-else"}
+				return sign(t, m.keys["key-1"], "key-1", claims)
+			},
+			nonce:   "n1",
+			wantErr: true,
+		},
+		{
+			name: "expired",
+			token: func() string {
+				claims := m.validClaims("n1")
+				claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Hour))
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+				return sign(t, m.keys["key-1"], "key-1", claims)
+			},
+			nonce:   "n1",
+			wantErr: true,
+		},
+		{
+			name: "no expiry",
+			token: func() string {
+				claims := m.validClaims("n1")
+				claims.ExpiresAt = nil
+				return sign(t, m.keys["key-1"], "key-1", claims)
+			},
+			nonce:   "n1",
+			wantErr: true,
+		},
+		{
+			name:    "nonce mismatch",
+			token:   func() string { return sign(t, m.keys["key-1"], "key-1", m.validClaims("n1")) },
+			nonce:   "n2",
+			wantErr: true,
+		},
+		{
+			name: "missing subject",
+			token: func() string {
+				claims := m.validClaims("n1")
+				claims.Subject = ""
+				return sign(t, m.keys["key-1"], "key-1", claims)
+			},
+			nonce:   "n1",
+			wantErr: true,
+		},
+		{
+			name: "unsigned",
+			token: func() string {
+				token, err := jwt.NewWithClaims(jwt.SigningMethodNone, m.validClaims("n1")).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			nonce:   "n1",
+			wantErr: true,
+		},
+		{
+			name: "HMAC signed with the public key",
+			token: func() string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, m.validClaims("n1"))
+				token.Header["kid"] = "key-1"
+				signed, err := token.SignedString(m.keys["key-1"].N.Bytes())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return signed
+			},
+			nonce:   "n1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := provider.VerifyIDToken(context.Background(), tt.token(), tt.nonce)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("VerifyIDToken accepted the token")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyIDToken: %v", err)
+			}
+			if claims.Subject != "subject-123" || claims.Email != "user@example.com" || !claims.EmailVerified {
+				t.Errorf("unexpected claims %+v", claims)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenAfterKeyRotation(t *testing.T) {
+	m := newMockProvider(t)
+	provider := m.discover(t)
+
+	if _, err := provider.VerifyIDToken(context.Background(), sign(t, m.keys["key-1"], "key-1", m.validClaims("n")), "n"); err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+
+	m.mutex.Lock()
+	m.keys = map[string]*rsa.PrivateKey{"key-2": newRSAKey(t)}
+	m.mutex.Unlock()
+	rotated := sign(t, m.keys["key-2"], "key-2", m.validClaims("n"))
+
+	// Within the refresh interval an unknown kid does not hit the provider again
+	if _, err := provider.VerifyIDToken(context.Background(), rotated, "n"); err == nil {
+		t.Fatal("unknown kid accepted before the keys were refetched")
+	}
+	if m.jwksHits != 1 {
+		t.Fatalf("jwks fetched %d times, want 1", m.jwksHits)
+	}
+
+	provider.keys.fetchedAt = time.Now().Add(-keyRefreshInterval)
+	if _, err := provider.VerifyIDToken(context.Background(), rotated, "n"); err != nil {
+		t.Fatalf("VerifyIDToken after rotation: %v", err)
+	}
+	if m.jwksHits != 2 {
+		t.Errorf("jwks fetched %d times, want 2", m.jwksHits)
+	}
+}
+
+func TestRegistryCachesDiscovery(t *testing.T) {
+	m := newMockProvider(t)
+	registry := NewRegistry(map[string]Config{"mock": m.config()})
+
+	first, err := registry.Provider(context.Background(), "mock")
+	if err != nil {
+		t.Fatalf("Provider: %v", err)
+	}
+	second, err := registry.Provider(context.Background(), "mock")
+	if err != nil {
+		t.Fatalf("Provider: %v", err)
+	}
+	if first != second {
+		t.Error("discovery ran again for a known provider")
+	}
+
+	if _, err := registry.Provider(context.Background(), "unknown"); err == nil {
+		t.Error("unknown provider returned without an error")
+	}
+}
+
+func TestConfigsFromEnv(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", "Google, gitlab ,broken")
+	t.Setenv("OIDC_GOOGLE_CLIENT_ID", "google-id")
+	t.Setenv("OIDC_GOOGLE_REDIRECT_URL", "https://app.example.com/cb")
+	t.Setenv("OIDC_GITLAB_ISSUER", "https://gitlab.example.com")
+	t.Setenv("OIDC_GITLAB_CLIENT_ID", "gitlab-id")
+	t.Setenv("OIDC_GITLAB_REDIRECT_URL", "https://app.example.com/cb")
+	t.Setenv("OIDC_GITLAB_SCOPES", "openid email")
+	t.Setenv("OIDC_BROKEN_CLIENT_ID", "broken-id")
+
+	configs := ConfigsFromEnv()
+	if len(configs) != 2 {
+		t.Fatalf("got %d providers, want 2: %v", len(configs), configs)
+	}
+	if configs["google"].Issuer != "https://accounts.google.com" {
+		t.Errorf("google issuer = %q", configs["google"].Issuer)
+	}
+	if strings.Join(configs["gitlab"].Scopes, " ") != "openid email" {
+		t.Errorf("gitlab scopes = %v", configs["gitlab"].Scopes)
+	}
+}
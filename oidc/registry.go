@@ -0,0 +1,82 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Registry discovers configured providers on first use and keeps them for later logins
+type Registry struct {
+	configs map[string]Config
+
+	mutex     sync.Mutex
+	providers map[string]*Provider
+}
+
+func NewRegistry(configs map[string]Config) *Registry {
+	return &Registry{configs: configs, providers: map[string]*Provider{}}
+}
+
+// ConfigsFromEnv reads providers named in OIDC_PROVIDERS (e.g. "google,gitlab"). Each provider NAME is configured
+// with OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_REDIRECT_URL and an
+// optional space separated OIDC_<NAME>_SCOPES.
+func ConfigsFromEnv() map[string]Config {
+	configs := map[string]Config{}
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		config := Config{
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if config.Issuer == "" && name == "google" {
+			config.Issuer = "https://accounts.google.com"
+		}
+		if config.Issuer == "" || config.ClientID == "" || config.RedirectURL == "" {
+			continue
+		}
+		configs[name] = config
+	}
+	return configs
+}
+
+// Names lists the configured providers
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.configs))
+	for name := range r.configs {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Provider returns the named provider, running discovery the first time it is needed. A failed discovery is
+// retried on the next call.
+func (r *Registry) Provider(ctx context.Context, name string) (*Provider, error) {
+	config, ok := r.configs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", name)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if provider, ok := r.providers[name]; ok {
+		return provider, nil
+	}
+	provider, err := Discover(ctx, config, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.providers[name] = provider
+	return provider, nil
+}
@@ -3,91 +3,259 @@ package routes
 import (
 	"portfolio/controllers"
 	"portfolio/middleware"
+	"portfolio/models"
+	"portfolio/storage"
 
 	"github.com/gin-gonic/gin"
 )
 
+func WellKnownRoutes(rootRoutes *gin.RouterGroup) {
+	rootRoutes.GET("/.well-known/jwks.json", controllers.GetJWKS())
+}
+
+// SitemapRoutes are served from the API's root, but the sitemap lists frontend pages, so the frontend at
+// PORTFOLIO_URL has to proxy /sitemap.xml, /sitemaps/* and /robots.txt here or host its own copies
+func SitemapRoutes(rootRoutes *gin.RouterGroup) {
+	rootRoutes.GET("/sitemap.xml", controllers.GetSitemap())
+	rootRoutes.GET("/sitemaps/:page", controllers.GetSitemapPage())
+	rootRoutes.GET("/robots.txt", controllers.GetRobotsTxt())
+}
+
 func VisitorRoutes(publicRoutes, authenticatedRoutes *gin.RouterGroup) {
 	publicRoutes.Use(middleware.VisitorDataMiddleware())
+	canRead := middleware.RequirePermission(models.PermissionVisitorsRead)
+	canEdit := middleware.RequirePermission(models.PermissionPortfolioEdit)
 
 	publicRoutes.POST("/visitor-log/create", controllers.CreateVisitorLog())
-	authenticatedRoutes.GET("/visitor-log/get-all", controllers.GetAllVisitorLogs())
-	authenticatedRoutes.GET("/visitor-log/get-one/:id", controllers.GetVisitorLogByID())
-	authenticatedRoutes.DELETE("/visitor-log/delete/:id", controllers.DeleteVisitorLog())
+	authenticatedRoutes.GET("/visitor-log/get-all", canRead, controllers.GetAllVisitorLogs())
+	authenticatedRoutes.GET("/visitor-log/get-one/:id", canRead, controllers.GetVisitorLogByID())
+	authenticatedRoutes.DELETE("/visitor-log/delete/:id", canEdit, controllers.DeleteVisitorLog())
 }
 
 func AuthRoutes(publicRoutes, authenticatedRoutes *gin.RouterGroup) {
 	publicRoutes.POST("/login", controllers.Login())
+	publicRoutes.POST("/login/2fa", controllers.VerifyAdminTwoFactor())
+	publicRoutes.POST("/refresh", controllers.RefreshToken(controllers.ClientPortfolio))
+	publicRoutes.POST("/logout", controllers.Logout(controllers.ClientPortfolio))
 }
 
 func LayoutRoutes(publicRoutes, authenticatedRoutes *gin.RouterGroup) {
-	publicRoutes.GET("/manage-layout", controllers.ManageLayout())
+	canEdit := middleware.RequirePermission(models.PermissionPortfolioEdit)
+
+	publicRoutes.GET("/manage-layout", middleware.OptionalAuthentication(), controllers.ManageLayout())
 
-	authenticatedRoutes.POST("/manage-layout", controllers.ManageLayout())
-	authenticatedRoutes.PUT("/manage-layout", controllers.ManageLayout())
+	authenticatedRoutes.POST("/manage-layout", canEdit, controllers.ManageLayout())
+	authenticatedRoutes.PUT("/manage-layout", canEdit, controllers.ManageLayout())
+	authenticatedRoutes.GET("/manage-layout/types", canEdit, controllers.GetLayoutTypes())
+	authenticatedRoutes.GET("/manage-layout/preview", canEdit, controllers.PreviewLayout())
+	authenticatedRoutes.GET("/manage-layout/revisions", canEdit, controllers.GetLayoutRevisions())
+	authenticatedRoutes.GET("/manage-layout/revisions/diff", canEdit, controllers.DiffLayoutRevisions())
+	authenticatedRoutes.POST("/manage-layout/revisions/restore", canEdit, controllers.RestoreLayoutRevision())
 }
 
 func CertificateRoutes(publicRoutes, authenticatedRoutes *gin.RouterGroup) {
-	publicRoutes.GET("/certificate/get-all", controllers.GetAllCertificates())
-	publicRoutes.GET("/certificate/get-one/:id", controllers.GetOneCertificate())
+	canEdit := middleware.RequirePermission(models.PermissionPortfolioEdit)
 
-	authenticatedRoutes.POST("/certificate/create", controllers.CreateCertificate())
-	authenticatedRoutes.PUT("/certificate/update/:id", controllers.UpdateCertificate())
-	authenticatedRoutes.DELETE("/certificate/delete/:id", controllers.DeleteCertificate())
+	// Signed in editors also see drafts, scheduled and archived certificates
+	optionalAuth := middleware.OptionalAuthentication()
+
+	publicRoutes.GET("/certificate/get-all", optionalAuth, controllers.GetAllCertificates())
+	publicRoutes.GET("/certificate/get-one/:id", optionalAuth, controllers.GetOneCertificate())
+
+	authenticatedRoutes.POST("/certificate/create", canEdit, controllers.CreateCertificate())
+	authenticatedRoutes.PUT("/certificate/update/:id", canEdit, controllers.UpdateCertificate())
+	authenticatedRoutes.DELETE("/certificate/delete/:id", canEdit, controllers.DeleteCertificate())
 }
 
 func ServiceRoutes(publicRoutes, authenticatedRoutes *gin.RouterGroup) {
-	publicRoutes.GET("/service/get-all", controllers.GetAllServices())
-	publicRoutes.GET("/service/get-one/:id", controllers.GetOneService())
+	canEdit := middleware.RequirePermission(models.PermissionPortfolioEdit)
+
+	// Signed in editors also see drafts, scheduled and archived services
+	optionalAuth := middleware.OptionalAuthentication()
+
+	publicRoutes.GET("/service/get-all", optionalAuth, controllers.GetAllServices())
+	publicRoutes.GET("/service/get-one/:id", optionalAuth, controllers.GetOneService())
 
-	authenticatedRoutes.POST("/service/create", controllers.CreateService())
-	authenticatedRoutes.PUT("/service/update/:id", controllers.UpdateService())
-	authenticatedRoutes.DELETE("/service/delete/:id", controllers.DeleteService())
+	authenticatedRoutes.POST("/service/create", canEdit, controllers.CreateService())
+	authenticatedRoutes.PUT("/service/update/:id", canEdit, controllers.UpdateService())
+	authenticatedRoutes.DELETE("/service/delete/:id", canEdit, controllers.DeleteService())
 }
 
 func ProjectRoutes(publicRoutes, authenticatedRoutes *gin.RouterGroup) {
-	publicRoutes.GET("/project/get-all", controllers.GetAllProjects())
-	publicRoutes.GET("/project/get-one/:id", controllers.GetOneProject())
+	canEdit := middleware.RequirePermission(models.PermissionPortfolioEdit)
+
+	// Signed in editors also see drafts, scheduled and archived projects
+	optionalAuth := middleware.OptionalAuthentication()
+
+	publicRoutes.GET("/project/get-all", optionalAuth, controllers.GetAllProjects())
+	publicRoutes.GET("/project/get-one/:id", optionalAuth, controllers.GetOneProject())
+
+	authenticatedRoutes.POST("/project/create", canEdit, controllers.CreateProject())
+	authenticatedRoutes.PUT("/project/update/:id", canEdit, controllers.UpdateProject())
+	authenticatedRoutes.PUT("/project/reorder", canEdit, controllers.ReorderProjects())
+	authenticatedRoutes.DELETE("/project/delete/:id", canEdit, controllers.DeleteProject())
+}
+
+func ResumeRoutes(publicRoutes, authenticatedRoutes *gin.RouterGroup) {
+	canEdit := middleware.RequirePermission(models.PermissionPortfolioEdit)
+
+	// Signed in editors also see drafts, scheduled and archived resume entries
+	optionalAuth := middleware.OptionalAuthentication()
+
+	publicRoutes.GET("/resume", optionalAuth, controllers.GetResume())
+
+	publicRoutes.GET("/experience/get-all", optionalAuth, controllers.GetAllExperience())
+	publicRoutes.GET("/experience/get-one/:id", optionalAuth, controllers.GetOneExperience())
+	authenticatedRoutes.POST("/experience/create", canEdit, controllers.CreateExperience())
+	authenticatedRoutes.PUT("/experience/update/:id", canEdit, controllers.UpdateExperience())
+	authenticatedRoutes.DELETE("/experience/delete/:id", canEdit, controllers.DeleteExperience())
+
+	publicRoutes.GET("/education/get-all", optionalAuth, controllers.GetAllEducation())
+	publicRoutes.GET("/education/get-one/:id", optionalAuth, controllers.GetOneEducation())
+	authenticatedRoutes.POST("/education/create", canEdit, controllers.CreateEducation())
+	authenticatedRoutes.PUT("/education/update/:id", canEdit, controllers.UpdateEducation())
+	authenticatedRoutes.DELETE("/education/delete/:id", canEdit, controllers.DeleteEducation())
 
-	authenticatedRoutes.POST("/project/create", controllers.CreateProject())
-	authenticatedRoutes.PUT("/project/update/:id", controllers.UpdateProject())
-	authenticatedRoutes.DELETE("/project/delete/:id", controllers.DeleteProject())
+	publicRoutes.GET("/skill/get-all", optionalAuth, controllers.GetAllSkills())
+	publicRoutes.GET("/skill/get-one/:id", optionalAuth, controllers.GetOneSkill())
+	authenticatedRoutes.POST("/skill/create", canEdit, controllers.CreateSkill())
+	authenticatedRoutes.PUT("/skill/update/:id", canEdit, controllers.UpdateSkill())
+	authenticatedRoutes.DELETE("/skill/delete/:id", canEdit, controllers.DeleteSkill())
+}
+
+func TranslationRoutes(authenticatedRoutes *gin.RouterGroup) {
+	canEdit := middleware.RequirePermission(models.PermissionPortfolioEdit)
+
+	authenticatedRoutes.GET("/translations/missing", canEdit, controllers.GetMissingTranslations())
+}
+
+func FeedRoutes(publicRoutes *gin.RouterGroup) {
+	publicRoutes.GET("/feed.xml", controllers.GetRSSFeed())
+	publicRoutes.GET("/atom.xml", controllers.GetAtomFeed())
+	publicRoutes.GET("/feed.json", controllers.GetJSONFeed())
+}
+
+func MediaRoutes(rootRoutes, authenticatedRoutes *gin.RouterGroup) {
+	canEdit := middleware.RequirePermission(models.PermissionPortfolioEdit)
+
+	// Files on local disk are served by the API itself
+	if local, ok := controllers.MediaStorage.(*storage.Local); ok {
+		rootRoutes.Static(local.ServePath(), local.Dir)
+	}
+
+	authenticatedRoutes.POST("/media/upload", canEdit, controllers.UploadMedia())
+	authenticatedRoutes.GET("/media/get-all", canEdit, controllers.GetAllMedia())
+	authenticatedRoutes.DELETE("/media/delete/:id", canEdit, controllers.DeleteMedia())
+}
+
+func PostRoutes(publicRoutes, authenticatedRoutes *gin.RouterGroup) {
+	canEdit := middleware.RequirePermission(models.PermissionPortfolioEdit)
+
+	publicRoutes.GET("/post/get-all", controllers.GetAllPosts())
+	publicRoutes.GET("/post/get-one/:slug", controllers.GetPostBySlug())
+
+	authenticatedRoutes.GET("/post/manage/get-all", canEdit, controllers.GetAllPostsAdmin())
+	authenticatedRoutes.GET("/post/manage/get-one/:id", canEdit, controllers.GetOnePostAdmin())
+	authenticatedRoutes.POST("/post/create", canEdit, controllers.CreatePost())
+	authenticatedRoutes.PUT("/post/update/:id", canEdit, controllers.UpdatePost())
+	authenticatedRoutes.DELETE("/post/delete/:id", canEdit, controllers.DeletePost())
 }
 
 func EmailRoutes(publicRoutes, authenticatedRoutes *gin.RouterGroup) {
+	canRead := middleware.RequirePermission(models.PermissionMessagesRead)
+	canEdit := middleware.RequirePermission(models.PermissionPortfolioEdit)
+
 	publicRoutes.POST("/email/create", controllers.CreateEmail())
 
-	authenticatedRoutes.GET("/email/get-all", controllers.GetAllEmails())
-	authenticatedRoutes.GET("/email/get-one/:id", controllers.GetOneEmail())
-	authenticatedRoutes.DELETE("/email/delete/:id", controllers.DeleteEmail())
+	authenticatedRoutes.GET("/email/get-all", canRead, controllers.GetAllEmails())
+	authenticatedRoutes.GET("/email/get-one/:id", canRead, controllers.GetOneEmail())
+	authenticatedRoutes.DELETE("/email/delete/:id", canEdit, controllers.DeleteEmail())
 }
 
 // Expense App
 func UserRoutes(publicRoutes, expenseRoutes *gin.RouterGroup, expenseAdminRoutes *gin.RouterGroup) {
+	canReadUsers := middleware.RequirePermission(models.PermissionUsersRead)
+	canWriteUsers := middleware.RequirePermission(models.PermissionUsersWrite)
+	canWriteRoles := middleware.RequirePermission(models.PermissionRolesWrite)
+	canWriteSettings := middleware.RequirePermission(models.PermissionSettingsWrite)
+	canImpersonate := middleware.RequirePermission(models.PermissionUsersImpersonate)
+	interactive := middleware.RequireInteractiveLogin()
+
+	publicRoutes.GET("/expense/registration-mode", controllers.GetRegistrationMode())
 	publicRoutes.POST("/expense/register", controllers.RegisterUser())
 	publicRoutes.POST("/expense/login", controllers.LoginUser())
+	publicRoutes.POST("/expense/login/2fa", controllers.VerifyLoginTwoFactor())
+	publicRoutes.POST("/expense/verify-email", controllers.VerifyEmail())
+	publicRoutes.POST("/expense/resend-verification", controllers.ResendVerificationEmail())
+	publicRoutes.POST("/expense/refresh", controllers.RefreshToken(controllers.ClientExpense))
+	publicRoutes.POST("/expense/logout", controllers.Logout(controllers.ClientExpense))
+	publicRoutes.GET("/expense/oauth/providers", controllers.GetOAuthProviders())
+	publicRoutes.POST("/expense/oauth/:provider/start", controllers.StartOAuthLogin())
+	publicRoutes.POST("/expense/oauth/:provider/callback", controllers.CompleteOAuthLogin())
 
 	expenseRoutes.GET("/me", controllers.GetCurrentUser())
-	expenseRoutes.PUT("/update-user-info", controllers.UpdateUserInfo())
-	expenseRoutes.PUT("/update-user-password", controllers.UpdateUserPassword())
+	expenseRoutes.POST("/impersonation/stop", controllers.StopImpersonation())
+	expenseRoutes.PUT("/update-user-info", interactive, controllers.UpdateUserInfo())
+	expenseRoutes.PUT("/update-user-password", interactive, controllers.UpdateUserPassword())
+	expenseRoutes.GET("/sessions", interactive, controllers.GetAllSessions())
+	expenseRoutes.DELETE("/sessions", interactive, controllers.RevokeAllSessions())
+	expenseRoutes.DELETE("/sessions/:id", interactive, controllers.RevokeSession())
+	expenseRoutes.POST("/2fa/setup", interactive, controllers.SetupTwoFactor())
+	expenseRoutes.POST("/2fa/enable", interactive, controllers.EnableTwoFactor())
+	expenseRoutes.POST("/2fa/disable", interactive, controllers.DisableTwoFactor())
+	expenseRoutes.POST("/2fa/recovery-codes", interactive, controllers.RegenerateRecoveryCodes())
+	expenseRoutes.GET("/api-keys", interactive, controllers.GetAllApiKeys())
+	expenseRoutes.POST("/api-keys", interactive, controllers.CreateApiKey())
+	expenseRoutes.DELETE("/api-keys/:id", interactive, controllers.DeleteApiKey())
+	expenseRoutes.POST("/oauth/:provider/link", interactive, controllers.StartOAuthLink())
+	expenseRoutes.GET("/account/export", interactive, controllers.ExportAccountData())
+	expenseRoutes.POST("/account/delete", interactive, controllers.RequestAccountDeletion())
+	expenseRoutes.DELETE("/account/delete", interactive, controllers.CancelAccountDeletion())
 
-	expenseAdminRoutes.GET("/get-all-users", controllers.GetAllUsers())
-	expenseAdminRoutes.PUT("/update-user-role", controllers.UpdateUserRole())
-	expenseAdminRoutes.DELETE("/delete-user/:id", controllers.DeleteUser())
+	expenseAdminRoutes.GET("/get-all-users", canReadUsers, controllers.GetAllUsers())
+	expenseAdminRoutes.GET("/users", canReadUsers, controllers.GetAllUsers())
+	expenseAdminRoutes.GET("/users/:id", canReadUsers, controllers.GetUser())
+	expenseAdminRoutes.PUT("/users/:id/suspend", canWriteUsers, controllers.SuspendUser())
+	expenseAdminRoutes.PUT("/users/:id/reactivate", canWriteUsers, controllers.ReactivateUser())
+	expenseAdminRoutes.POST("/users/:id/impersonate", canImpersonate, controllers.ImpersonateUser())
+	expenseAdminRoutes.GET("/audit-logs", canReadUsers, controllers.GetAuditLogs())
+	expenseAdminRoutes.GET("/invites", canReadUsers, controllers.GetAllInvites())
+	expenseAdminRoutes.GET("/invites/:id", canReadUsers, controllers.GetOneInvite())
+	expenseAdminRoutes.POST("/invites", canWriteUsers, controllers.CreateInvite())
+	expenseAdminRoutes.DELETE("/invites/:id", canWriteUsers, controllers.RevokeInvite())
+	expenseAdminRoutes.GET("/stats/registrations", canReadUsers, controllers.GetRegistrationStats())
+	expenseAdminRoutes.GET("/stats/active-users", canReadUsers, controllers.GetActiveUserStats())
+	expenseAdminRoutes.GET("/stats/content", canReadUsers, controllers.GetContentStats())
+	expenseAdminRoutes.GET("/stats/top-categories", canReadUsers, controllers.GetTopCategoryStats())
+	expenseAdminRoutes.PUT("/update-user-role", canWriteUsers, controllers.UpdateUserRole())
+	expenseAdminRoutes.DELETE("/delete-user/:id", canWriteUsers, controllers.DeleteUser())
+	expenseAdminRoutes.POST("/unlock-account", canWriteUsers, controllers.UnlockAccount())
+	expenseAdminRoutes.GET("/settings", canWriteSettings, controllers.GetSettings())
+	expenseAdminRoutes.PUT("/settings", canWriteSettings, controllers.UpdateSettings())
+	expenseAdminRoutes.GET("/roles", canReadUsers, controllers.GetAllRoles())
+	expenseAdminRoutes.POST("/roles", canWriteRoles, controllers.CreateRole())
+	expenseAdminRoutes.PUT("/roles/:name", canWriteRoles, controllers.UpdateRole())
+	expenseAdminRoutes.DELETE("/roles/:name", canWriteRoles, controllers.DeleteRole())
 }
 
 func ExpenseCategoryRoutes(expenseRoutes *gin.RouterGroup) {
-	expenseRoutes.POST("/create-category", controllers.CreateExpenseCategory())
-	expenseRoutes.PUT("/update-category/:id", controllers.UpdateExpenseCategory())
-	expenseRoutes.DELETE("/delete-category/:id", controllers.DeleteExpenseCategory())
-	expenseRoutes.GET("/get-all-categories", controllers.GetAllExpenseCategories())
-	expenseRoutes.GET("/get-one-category/:id", controllers.GetOneExpenseCategory())
+	canRead := middleware.RequirePermission(models.PermissionExpensesRead)
+	canWrite := middleware.RequirePermission(models.PermissionExpensesWrite)
+
+	expenseRoutes.POST("/create-category", canWrite, controllers.CreateExpenseCategory())
+	expenseRoutes.PUT("/update-category/:id", canWrite, controllers.UpdateExpenseCategory())
+	expenseRoutes.DELETE("/delete-category/:id", canWrite, controllers.DeleteExpenseCategory())
+	expenseRoutes.GET("/get-all-categories", canRead, controllers.GetAllExpenseCategories())
+	expenseRoutes.GET("/get-one-category/:id", canRead, controllers.GetOneExpenseCategory())
 }
 
 func ExpenseItemRoutes(expenseRoutes *gin.RouterGroup) {
-	expenseRoutes.POST("/create-item", controllers.CreateExpenseItem())
-	expenseRoutes.PUT("/update-item/:id", controllers.UpdateExpenseItem())
-	expenseRoutes.DELETE("/delete-item/:id", controllers.DeleteExpenseItem())
-	expenseRoutes.GET("/get-all-incomes", controllers.GetAllIncomes())
-	expenseRoutes.GET("/get-all-outcomes", controllers.GetAllOutcomes())
+	canRead := middleware.RequirePermission(models.PermissionExpensesRead)
+	canWrite := middleware.RequirePermission(models.PermissionExpensesWrite)
+
+	expenseRoutes.POST("/create-item", canWrite, controllers.CreateExpenseItem())
+	expenseRoutes.PUT("/update-item/:id", canWrite, controllers.UpdateExpenseItem())
+	expenseRoutes.DELETE("/delete-item/:id", canWrite, controllers.DeleteExpenseItem())
+	expenseRoutes.GET("/get-all-incomes", canRead, controllers.GetAllIncomes())
+	expenseRoutes.GET("/get-all-outcomes", canRead, controllers.GetAllOutcomes())
 }
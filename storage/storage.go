@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Storage keeps uploaded files under a key and knows the public URL they are served from
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+var errInvalidKey = errors.New("invalid storage key")
+
+// FromEnv configures the backend chosen by MEDIA_STORAGE, local disk by default. Local files are written to
+// MEDIA_DIR, "uploads" by default, which must be a mounted volume when running in a container or every redeploy
+// loses them. They are served from MEDIA_BASE_URL, or API_URL + "/media" when it is unset; either way the URL must
+// be absolute, because media URLs are stored in content and shown by a frontend on another origin.
+func FromEnv() (Storage, error) {
+	switch backend := os.Getenv("MEDIA_STORAGE"); backend {
+	case "", "local":
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		baseURL := os.Getenv("MEDIA_BASE_URL")
+		if baseURL == "" && os.Getenv("API_URL") != "" {
+			baseURL = strings.TrimRight(os.Getenv("API_URL"), "/") + "/media"
+		}
+		if err := checkBaseURL(baseURL); err != nil {
+			return nil, err
+		}
+		return NewLocal(dir, baseURL), nil
+	default:
+		return nil, fmt.Errorf("unknown media storage %q", backend)
+	}
+}
+
+func checkBaseURL(baseURL string) error {
+	if baseURL == "" {
+		return errors.New("MEDIA_BASE_URL or API_URL must be set")
+	}
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("MEDIA_BASE_URL: %w", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("media base URL %q is not an absolute http or https URL", baseURL)
+	}
+	return nil
+}
+
+// Local stores files in a directory on disk that the API serves itself
+type Local struct {
+	Dir     string
+	BaseURL string
+}
+
+func NewLocal(dir string, baseURL string) *Local {
+	return &Local{Dir: dir, BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// ServePath is the route the files should be served on, taken from the base URL
+func (l *Local) ServePath() string {
+	parsed, err := url.Parse(l.BaseURL)
+	if err != nil || parsed.Path == "" {
+		return "/media"
+	}
+	return parsed.Path
+}
+
+func (l *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean != "/"+key {
+		return "", errInvalidKey
+	}
+	return filepath.Join(l.Dir, filepath.FromSlash(clean)), nil
+}
+
+// Put writes the file through a temporary file so readers never see a partial upload
+func (l *Local) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) URL(key string) string {
+	return l.BaseURL + "/" + key
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFromEnvRequiresAbsoluteBaseURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		apiURL  string
+		want    string
+		wantErr bool
+	}{
+		{"media base url", "https://cdn.example.com/media/", "", "https://cdn.example.com/media", false},
+		{"from api url", "", "https://api.example.com/", "https://api.example.com/media", false},
+		{"unset", "", "", "", true},
+		{"relative", "/media", "", "", true},
+		{"no scheme", "cdn.example.com/media", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MEDIA_STORAGE", "local")
+			t.Setenv("MEDIA_BASE_URL", tt.baseURL)
+			t.Setenv("API_URL", tt.apiURL)
+
+			store, err := FromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("FromEnv accepted the configuration")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromEnv: %v", err)
+			}
+			if got := store.(*Local).BaseURL; got != tt.want {
+				t.Errorf("base URL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromEnvUnknownBackend(t *testing.T) {
+	t.Setenv("MEDIA_STORAGE", "floppy")
+	if _, err := FromEnv(); err == nil {
+		t.Error("FromEnv accepted an unknown backend")
+	}
+}
+
+func TestLocalRejectsKeysOutsideDir(t *testing.T) {
+	dir := t.TempDir()
+	local := NewLocal(filepath.Join(dir, "uploads"), "https://api.example.com/media")
+	ctx := context.Background()
+
+	for _, key := range []string{"", "../escape.txt", "a/../../escape.txt", "/absolute.txt", "a//b.txt", "a/./b.txt"} {
+		if err := local.Put(ctx, key, []byte("x"), "text/plain"); err == nil {
+			t.Errorf("Put accepted key %q", key)
+		}
+		if err := local.Delete(ctx, key); err == nil {
+			t.Errorf("Delete accepted key %q", key)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.txt")); !os.IsNotExist(err) {
+		t.Error("a file was written outside the storage directory")
+	}
+}
+
+func TestLocalPutDelete(t *testing.T) {
+	local := NewLocal(t.TempDir(), "https://api.example.com/media/")
+	ctx := context.Background()
+
+	if err := local.Put(ctx, "images/abc.png", []byte("png"), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(local.Dir, "images", "abc.png"))
+	if err != nil || string(data) != "png" {
+		t.Fatalf("stored file = %q, %v", data, err)
+	}
+	if got := local.URL("images/abc.png"); got != "https://api.example.com/media/images/abc.png" {
+		t.Errorf("URL = %q", got)
+	}
+	if got := local.ServePath(); got != "/media" {
+		t.Errorf("ServePath = %q", got)
+	}
+
+	if err := local.Delete(ctx, "images/abc.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := local.Delete(ctx, "images/abc.png"); err != nil {
+		t.Errorf("deleting a missing file: %v", err)
+	}
+}
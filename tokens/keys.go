@@ -0,0 +1,252 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keySet holds the key used to sign new tokens and every key that is still accepted for verification.
+// Asymmetric keys are loaded from JWT_KEYS_DIR, one PEM file per key named <kid>.pem; JWT_ACTIVE_KID picks
+// the signing key and the remaining files stay valid for verification until they are removed, which allows
+// rotation without logging everybody out. Without JWT_KEYS_DIR the legacy HS256 SECRET_KEY is used.
+type keySet struct {
+	signingKID    string
+	signingKey    crypto.Signer
+	signingMethod jwt.SigningMethod
+
+	verificationKeys map[string]crypto.PublicKey
+
+	hmacSecret []byte
+}
+
+var (
+	loadedKeys   *keySet
+	loadKeysErr  error
+	loadKeysOnce sync.Once
+)
+
+// LoadKeys reads the signing configuration from the environment. It runs once; later calls return the
+// first result, so it should be called after the environment has been loaded.
+func LoadKeys() error {
+	loadKeysOnce.Do(func() {
+		loadedKeys, loadKeysErr = readKeySet()
+	})
+	return loadKeysErr
+}
+
+func keys() (*keySet, error) {
+	if err := LoadKeys(); err != nil {
+		return nil, err
+	}
+	return loadedKeys, nil
+}
+
+func readKeySet() (*keySet, error) {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		secret := os.Getenv("SECRET_KEY")
+		if secret == "" {
+			return nil, errors.New("either JWT_KEYS_DIR or SECRET_KEY must be set")
+		}
+		return &keySet{signingMethod: jwt.SigningMethodHS256, hmacSecret: []byte(secret)}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	set := &keySet{verificationKeys: map[string]crypto.PublicKey{}}
+	signers := map[string]crypto.Signer{}
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		signer, publicKey, err := parsePEMKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		set.verificationKeys[kid] = publicKey
+		if signer != nil {
+			signers[kid] = signer
+		}
+	}
+
+	activeKID := os.Getenv("JWT_ACTIVE_KID")
+	signer, ok := signers[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("JWT_ACTIVE_KID %q does not name a private key in %s", activeKID, dir)
+	}
+	set.signingKID = activeKID
+	set.signingKey = signer
+
+	switch signer.(type) {
+	case *rsa.PrivateKey:
+		set.signingMethod = jwt.SigningMethodRS256
+	case ed25519.PrivateKey:
+		set.signingMethod = jwt.SigningMethodEdDSA
+	}
+
+	return set, nil
+}
+
+// parsePEMKey accepts RSA or Ed25519 keys. Private keys can sign and verify, public keys only verify.
+func parsePEMKey(data []byte) (crypto.Signer, crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, key.Public(), nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			return key, key.Public(), nil
+		case ed25519.PrivateKey:
+			return key, key.Public(), nil
+		}
+		return nil, nil, errors.New("unsupported private key type")
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch key := key.(type) {
+		case *rsa.PublicKey, ed25519.PublicKey:
+			return nil, key, nil
+		}
+		return nil, nil, errors.New("unsupported public key type")
+	}
+	return nil, nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+// sign signs the claims with the active key, stamping its kid in the header
+func sign(claims jwt.Claims) (string, error) {
+	set, err := keys()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(set.signingMethod, claims)
+	if set.hmacSecret != nil {
+		return token.SignedString(set.hmacSecret)
+	}
+	token.Header["kid"] = set.signingKID
+	return token.SignedString(set.signingKey)
+}
+
+// verificationKey resolves the key for a token from its kid header
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	set, err := keys()
+	if err != nil {
+		return nil, err
+	}
+
+	if set.hmacSecret != nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return set.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := set.verificationKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+	case ed25519.PublicKey:
+		if _, ok := token.Method.(*jwt.SigningMethodEd25519); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+	}
+	return key, nil
+}
+
+// JWK is a single public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns every public verification key. It is empty when tokens are signed with the shared HS256 secret.
+func JWKS() (JWKSet, error) {
+	jwks := JWKSet{Keys: []JWK{}}
+
+	set, err := keys()
+	if err != nil {
+		return jwks, err
+	}
+
+	kids := make([]string, 0, len(set.verificationKeys))
+	for kid := range set.verificationKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	for _, kid := range kids {
+		switch key := set.verificationKeys[kid].(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: jwt.SigningMethodRS256.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "OKP",
+				Kid: kid,
+				Use: "sig",
+				Alg: jwt.SigningMethodEdDSA.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(key),
+			})
+		}
+	}
+	return jwks, nil
+}
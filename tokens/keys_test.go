@@ -0,0 +1,204 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writePEM(t *testing.T, dir, kid, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeRSAKey(t *testing.T, dir, kid string) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, kid, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+	return key
+}
+
+func writeEd25519Key(t *testing.T, dir, kid string) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, kid, "PRIVATE KEY", der)
+	return key
+}
+
+// useKeys loads the keys in dir as if the server had started with them, restoring the previous keys after the test
+func useKeys(t *testing.T, dir, activeKID string) {
+	t.Helper()
+	if err := LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("JWT_KEYS_DIR", dir)
+	t.Setenv("JWT_ACTIVE_KID", activeKID)
+
+	set, err := readKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := loadedKeys
+	loadedKeys = set
+	t.Cleanup(func() { loadedKeys = previous })
+}
+
+func signedHeader(t *testing.T, signed string) (kid, alg string) {
+	t.Helper()
+	claims, msg := ValidateToken(signed)
+	if msg != "" || claims == nil {
+		t.Fatalf("token did not validate: %s", msg)
+	}
+	var header struct {
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.Split(signed, ".")[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		t.Fatal(err)
+	}
+	return header.Kid, header.Alg
+}
+
+func TestSigningUsesActiveKey(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "rsa-2024")
+	writeEd25519Key(t, dir, "ed-2025")
+
+	tests := []struct {
+		activeKID string
+		wantAlg   string
+	}{
+		{"rsa-2024", "RS256"},
+		{"ed-2025", "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		useKeys(t, dir, tt.activeKID)
+		signed, err := TokenGenerator("ada@example.com", "user-id", "user", "session-id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		kid, alg := signedHeader(t, signed)
+		if kid != tt.activeKID || alg != tt.wantAlg {
+			t.Errorf("with JWT_ACTIVE_KID=%s the token was signed with kid %q and alg %q, want %q and %q", tt.activeKID, kid, alg, tt.activeKID, tt.wantAlg)
+		}
+	}
+}
+
+func TestRetiredKeyStillVerifiesDuringRotation(t *testing.T) {
+	dir := t.TempDir()
+	retired := writeRSAKey(t, dir, "old")
+
+	useKeys(t, dir, "old")
+	oldToken, err := TokenGenerator("ada@example.com", "user-id", "user", "session-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotate: the new key signs, the old one is kept as a public key only
+	writeEd25519Key(t, dir, "new")
+	publicDER, err := x509.MarshalPKIXPublicKey(retired.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, "old", "PUBLIC KEY", publicDER)
+	useKeys(t, dir, "new")
+
+	if _, msg := ValidateToken(oldToken); msg != "" {
+		t.Errorf("a token signed with the retired key was rejected during rotation: %s", msg)
+	}
+	newToken, err := TokenGenerator("ada@example.com", "user-id", "user", "session-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid, _ := signedHeader(t, newToken); kid != "new" {
+		t.Errorf("after rotation tokens are signed with kid %q, want %q", kid, "new")
+	}
+
+	// Once the retired key is removed its tokens stop verifying
+	if err := os.Remove(filepath.Join(dir, "old.pem")); err != nil {
+		t.Fatal(err)
+	}
+	useKeys(t, dir, "new")
+	if _, msg := ValidateToken(oldToken); msg == "" {
+		t.Error("a token signed with a removed key was accepted")
+	}
+}
+
+func TestJWKSPublishesOnlyPublicKeys(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := writeRSAKey(t, dir, "a-rsa")
+	edKey := writeEd25519Key(t, dir, "b-ed")
+	useKeys(t, dir, "a-rsa")
+
+	jwks, err := JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []JWK{
+		{
+			Kty: "RSA",
+			Kid: "a-rsa",
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+			E:   "AQAB",
+		},
+		{
+			Kty: "OKP",
+			Kid: "b-ed",
+			Use: "sig",
+			Alg: "EdDSA",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(edKey.Public().(ed25519.PublicKey)),
+		},
+	}
+	if !reflect.DeepEqual(jwks.Keys, want) {
+		t.Errorf("JWKS() = %+v, want %+v", jwks.Keys, want)
+	}
+
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range document.Keys {
+		for _, private := range []string{"d", "p", "q", "dp", "dq", "qi"} {
+			if _, ok := key[private]; ok {
+				t.Errorf("key %v publishes private member %q", key["kid"], private)
+			}
+		}
+	}
+}
@@ -1,54 +1,130 @@
 package token
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"os"
 	"time"
 
-	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 type SignedDetails struct {
-	Email   string
-	User_ID string
-	Role    int
-	jwt.StandardClaims
+	Email      string
+	User_ID    string
+	Role       string
+	Session_ID string
+	Purpose    string
+	jwt.RegisteredClaims
 }
 
-var SECRET_KEY = os.Getenv("SECRET_KEY")
+// PurposeTwoFactor marks a challenge token that can only be exchanged for a full token after a valid second factor
+const PurposeTwoFactor = "2fa"
 
-func TokenGenerator(email string, userId string, role int) (signedtoken string, err error) {
+const challengeTokenTTL = 5 * time.Minute
+
+// AccessTokenTTL reads ACCESS_TOKEN_TTL (e.g. "15m"), defaulting to fifteen minutes
+func AccessTokenTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return 15 * time.Minute
+}
+
+// RefreshTokenTTL reads REFRESH_TOKEN_TTL (e.g. "720h"), defaulting to thirty days
+func RefreshTokenTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return time.Hour * 24 * 30
+}
+
+// registeredClaims sets the standard claims shared by every token we issue
+func registeredClaims(ttl time.Duration) jwt.RegisteredClaims {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Issuer:    os.Getenv("JWT_ISSUER"),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		claims.Audience = jwt.ClaimStrings{audience}
+	}
+	return claims
+}
+
+// parserOptions requires the claims registeredClaims stamps, so a token issued for another JWT_ISSUER or
+// JWT_AUDIENCE sharing the same keys is rejected
+func parserOptions() []jwt.ParserOption {
+	options := []jwt.ParserOption{jwt.WithExpirationRequired()}
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+	return options
+}
+
+// TokenGenerator issues a short-lived access token; long-lived sessions are kept alive with refresh tokens
+func TokenGenerator(email string, userId string, role string, sessionId string) (signedtoken string, err error) {
 	claims := &SignedDetails{
-		Email:   email,
-		User_ID: userId,
-		Role:    role,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * 24 * 30).Unix(),
-		},
-	}
-	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(SECRET_KEY))
-	if err != nil {
+		Email:            email,
+		User_ID:          userId,
+		Role:             role,
+		Session_ID:       sessionId,
+		RegisteredClaims: registeredClaims(AccessTokenTTL()),
+	}
+	return sign(claims)
+}
+
+// ChallengeTokenGenerator issues a short-lived token proving the password step of a two-step login succeeded.
+// Its ID lets the second step accept it only once.
+func ChallengeTokenGenerator(email string, userId string, role string) (signedtoken string, err error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
 		return "", err
 	}
-	return token, err
+
+	claims := &SignedDetails{
+		Email:            email,
+		User_ID:          userId,
+		Role:             role,
+		Purpose:          PurposeTwoFactor,
+		RegisteredClaims: registeredClaims(challengeTokenTTL),
+	}
+	claims.ID = hex.EncodeToString(id)
+	return sign(claims)
 }
 
+// ValidateToken validates an access token; challenge tokens are rejected
 func ValidateToken(signedtoken string) (claims *SignedDetails, msg string) {
-	token, err := jwt.ParseWithClaims(signedtoken, &SignedDetails{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(SECRET_KEY), nil
-	})
+	claims, msg = parseToken(signedtoken)
+	if msg == "" && claims.Purpose != "" {
+		return nil, "The Token is invalid"
+	}
+	return claims, msg
+}
 
+// ValidateChallengeToken validates a two-factor challenge token
+func ValidateChallengeToken(signedtoken string) (claims *SignedDetails, msg string) {
+	claims, msg = parseToken(signedtoken)
+	if msg == "" && (claims.Purpose != PurposeTwoFactor || claims.ID == "") {
+		return nil, "The Token is invalid"
+	}
+	return claims, msg
+}
+
+func parseToken(signedtoken string) (claims *SignedDetails, msg string) {
+	token, err := jwt.ParseWithClaims(signedtoken, &SignedDetails{}, verificationKey, parserOptions()...)
 	if err != nil {
 		msg = err.Error()
 		return
 	}
 	claims, ok := token.Claims.(*SignedDetails)
-	if !ok {
+	if !ok || !token.Valid {
 		msg = "The Token is invalid"
 		return
 	}
-	if claims.ExpiresAt < time.Now().Local().Unix() {
-		msg = "token is expired"
-		return
-	}
 	return claims, msg
 }
@@ -0,0 +1,81 @@
+package token
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("SECRET_KEY", "test-secret")
+	os.Exit(m.Run())
+}
+
+func TestChallengeTokensAreSeparateFromAccessTokens(t *testing.T) {
+	challenge, err := ChallengeTokenGenerator("ada@example.com", "user-id", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	access, err := TokenGenerator("ada@example.com", "user-id", "user", "session-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, msg := ValidateToken(challenge); msg == "" {
+		t.Error("a challenge token was accepted as an access token")
+	}
+	if _, msg := ValidateChallengeToken(access); msg == "" {
+		t.Error("an access token was accepted as a challenge token")
+	}
+}
+
+func TestChallengeTokensHaveUniqueIDs(t *testing.T) {
+	first, _ := ChallengeTokenGenerator("ada@example.com", "user-id", "user")
+	second, _ := ChallengeTokenGenerator("ada@example.com", "user-id", "user")
+
+	firstClaims, msg := ValidateChallengeToken(first)
+	if msg != "" {
+		t.Fatal(msg)
+	}
+	secondClaims, msg := ValidateChallengeToken(second)
+	if msg != "" {
+		t.Fatal(msg)
+	}
+	if firstClaims.ID == "" || firstClaims.ID == secondClaims.ID {
+		t.Errorf("challenge token IDs %q and %q are not unique", firstClaims.ID, secondClaims.ID)
+	}
+}
+
+func TestChallengeTokenWithoutIDIsRejected(t *testing.T) {
+	signed, err := sign(&SignedDetails{User_ID: "user-id", Purpose: PurposeTwoFactor, RegisteredClaims: registeredClaims(challengeTokenTTL)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, msg := ValidateChallengeToken(signed); msg == "" {
+		t.Error("a challenge token without an ID was accepted, so it could not be used only once")
+	}
+}
+
+func TestTokensFromAnotherIssuerOrAudienceAreRejected(t *testing.T) {
+	issue := func(issuer, audience string) string {
+		t.Setenv("JWT_ISSUER", issuer)
+		t.Setenv("JWT_AUDIENCE", audience)
+		signed, err := TokenGenerator("ada@example.com", "user-id", "user", "session-id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	foreignIssuer := issue("https://other.example.com", "expense-app")
+	foreignAudience := issue("https://api.example.com", "other-app")
+	own := issue("https://api.example.com", "expense-app")
+
+	if _, msg := ValidateToken(own); msg != "" {
+		t.Errorf("a token from the configured issuer and audience was rejected: %s", msg)
+	}
+	if _, msg := ValidateToken(foreignIssuer); msg == "" {
+		t.Error("a token from another issuer was accepted")
+	}
+	if _, msg := ValidateToken(foreignAudience); msg == "" {
+		t.Error("a token for another audience was accepted")
+	}
+}